@@ -0,0 +1,218 @@
+package cgroups
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Runtime string
+
+const (
+	RuntimeUnknown    Runtime = ""
+	RuntimeDocker     Runtime = "docker"
+	RuntimeContainerd Runtime = "containerd"
+	RuntimeCRIO       Runtime = "cri-o"
+	RuntimePodman     Runtime = "podman"
+	RuntimeLXC        Runtime = "lxc"
+	RuntimeNspawn     Runtime = "systemd-nspawn"
+	RuntimeGarden     Runtime = "garden"
+)
+
+type QoSClass string
+
+const (
+	QoSClassGuaranteed QoSClass = "Guaranteed"
+	QoSClassBurstable  QoSClass = "Burstable"
+	QoSClassBestEffort QoSClass = "BestEffort"
+)
+
+type ContainerInfo struct {
+	Runtime     Runtime
+	ContainerID string
+	PodUID      string
+	QoSClass    QoSClass
+}
+
+type scopePattern struct {
+	regex   *regexp.Regexp
+	runtime Runtime
+}
+
+var (
+	containerIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+	// static pods run with the 32 hex digit config hash as their pod UID
+	podUIDRegex   = regexp.MustCompile(`^(?:kubepods-(?:burstable-|besteffort-)?)?pod([0-9a-fA-F]{8}[-_][0-9a-fA-F]{4}[-_][0-9a-fA-F]{4}[-_][0-9a-fA-F]{4}[-_][0-9a-fA-F]{12}|[0-9a-fA-F]{32})(?:\.slice)?$`)
+	gardenIDRegex = regexp.MustCompile(`^[0-9a-zA-Z-]{8,}$`)
+	// the systemd driver of cgroup v1 keeps the cgroupsPath of the OCI spec,
+	// "<slice>:<prefix>:<id>", as the last segment
+	systemdCgroupsPathRegex = regexp.MustCompile(`^([^:]+\.slice):([0-9a-zA-Z-]+):([0-9a-fA-F]{64})$`)
+
+	// scopePatterns match the systemd cgroup driver scopes and the prefixed
+	// cgroupfs directories of the runtimes. Conmon scopes of CRI-O and podman
+	// hold the monitor process instead of the container and are skipped.
+	scopePatterns = []scopePattern{
+		{regex: regexp.MustCompile(`^docker-([0-9a-fA-F]{64})\.scope$`), runtime: RuntimeDocker},
+		{regex: regexp.MustCompile(`^cri-containerd-([0-9a-fA-F]{64})\.scope$`), runtime: RuntimeContainerd},
+		{regex: regexp.MustCompile(`^nerdctl-([0-9a-fA-F]{64})\.scope$`), runtime: RuntimeContainerd},
+		{regex: regexp.MustCompile(`^crio-([0-9a-fA-F]{64})(?:\.scope)?$`), runtime: RuntimeCRIO},
+		{regex: regexp.MustCompile(`^libpod-([0-9a-fA-F]{64})(?:\.scope)?$`), runtime: RuntimePodman},
+		{regex: regexp.MustCompile(`^lxc\.payload\.(.+)$`), runtime: RuntimeLXC},
+		{regex: regexp.MustCompile(`^systemd-nspawn@(.+)\.service$`), runtime: RuntimeNspawn},
+	}
+)
+
+// Parse returns the container information found in the cgroup paths of a
+// process. The first path which identifies a container wins, so cgroup v2
+// unified paths should be passed in the order of /proc/<pid>/cgroup.
+func Parse(paths []string) (ContainerInfo, bool) {
+	var fallback *ContainerInfo
+
+	for _, path := range paths {
+		info, ok := ParsePath(path)
+		if !ok {
+			continue
+		}
+
+		// prefer a path naming the runtime or the pod over a bare id
+		if info.Runtime != RuntimeUnknown || info.PodUID != "" {
+			return info, true
+		}
+
+		if fallback == nil {
+			fallback = &info
+		}
+	}
+
+	if fallback != nil {
+		return *fallback, true
+	}
+
+	return ContainerInfo{}, false
+}
+
+// ParsePath returns the container information of a single cgroup path for
+// both the cgroupfs and systemd cgroup drivers. Segments are scanned from
+// the innermost one, so nested setups like kind report the inner container.
+func ParsePath(path string) (ContainerInfo, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	// split into the slice and the scope the cgroup v2 systemd driver uses
+	if last := len(segments) - 1; last >= 0 {
+		if m := systemdCgroupsPathRegex.FindStringSubmatch(segments[last]); len(m) > 3 {
+			segments = append(segments[:last], m[1], m[2]+"-"+m[3]+".scope")
+		}
+	}
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		info, ok := parseContainerSegment(segments, i)
+		if !ok {
+			continue
+		}
+
+		info.PodUID, info.QoSClass = parsePodSegments(segments[:i])
+
+		return info, true
+	}
+
+	return ContainerInfo{}, false
+}
+
+func parseContainerSegment(segments []string, i int) (ContainerInfo, bool) {
+	segment := segments[i]
+	parent := ""
+	if i > 0 {
+		parent = segments[i-1]
+	}
+
+	for _, p := range scopePatterns {
+		if m := p.regex.FindStringSubmatch(segment); len(m) > 1 {
+			return ContainerInfo{
+				Runtime:     p.runtime,
+				ContainerID: unescapeSystemdName(m[1]),
+			}, true
+		}
+	}
+
+	switch {
+	case parent == "machine.slice" && strings.HasPrefix(segment, "machine-") && strings.HasSuffix(segment, ".scope") && !strings.HasPrefix(segment, "machine-qemu"):
+		return ContainerInfo{
+			Runtime:     RuntimeNspawn,
+			ContainerID: unescapeSystemdName(strings.TrimSuffix(strings.TrimPrefix(segment, "machine-"), ".scope")),
+		}, true
+	case parent == "lxc" && segment != "":
+		return ContainerInfo{
+			Runtime:     RuntimeLXC,
+			ContainerID: segment,
+		}, true
+	case parent == "garden" && gardenIDRegex.MatchString(segment):
+		return ContainerInfo{
+			Runtime:     RuntimeGarden,
+			ContainerID: segment,
+		}, true
+	case containerIDRegex.MatchString(segment):
+		info := ContainerInfo{
+			ContainerID: segment,
+		}
+		if parent == "docker" {
+			info.Runtime = RuntimeDocker
+		}
+
+		return info, true
+	}
+
+	return ContainerInfo{}, false
+}
+
+func parsePodSegments(segments []string) (string, QoSClass) {
+	var podUID string
+	var qos QoSClass
+	var kubepods bool
+
+	for _, segment := range segments {
+		name := strings.TrimSuffix(segment, ".slice")
+
+		switch {
+		case name == "kubepods":
+			kubepods = true
+		case name == "burstable" || name == "kubepods-burstable":
+			kubepods = true
+			qos = QoSClassBurstable
+		case name == "besteffort" || name == "kubepods-besteffort":
+			kubepods = true
+			qos = QoSClassBestEffort
+		}
+
+		if m := podUIDRegex.FindStringSubmatch(segment); len(m) > 1 {
+			podUID = strings.ReplaceAll(m[1], "_", "-")
+		}
+	}
+
+	if podUID != "" && qos == "" && kubepods {
+		qos = QoSClassGuaranteed
+	}
+
+	return podUID, qos
+}
+
+// unescapeSystemdName reverts the \xNN escaping systemd applies to unit names.
+func unescapeSystemdName(name string) string {
+	if !strings.Contains(name, `\x`) {
+		return name
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+3 < len(name) && name[i+1] == 'x' {
+			if v, err := strconv.ParseUint(name[i+2:i+4], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+
+				continue
+			}
+		}
+		b.WriteByte(name[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,250 @@
+package cgroups_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gezacorp/metadatax/cgroups"
+)
+
+const (
+	containerID = "2ce296b740c37b0793e7c95761b32f6a26d8b98b3c0e4e7d5a6032f71520ecad"
+	podUID      = "5831c41b-55ba-4e82-9c6e-2d3ad9d8bfe9"
+	podUIDSlice = "5831c41b_55ba_4e82_9c6e_2d3ad9d8bfe9"
+	configHash  = "8d4e1a3b7c2f9e6d5a0b1c2d3e4f5a6b"
+)
+
+func TestParsePath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected cgroups.ContainerInfo
+		found    bool
+	}{
+		{
+			name:     "docker cgroupfs",
+			path:     "/docker/" + containerID,
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeDocker, ContainerID: containerID},
+			found:    true,
+		},
+		{
+			name:     "docker systemd",
+			path:     "/system.slice/docker-" + containerID + ".scope",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeDocker, ContainerID: containerID},
+			found:    true,
+		},
+		{
+			name:     "kubernetes cgroupfs burstable",
+			path:     "/kubepods/burstable/pod" + podUID + "/" + containerID,
+			expected: cgroups.ContainerInfo{ContainerID: containerID, PodUID: podUID, QoSClass: cgroups.QoSClassBurstable},
+			found:    true,
+		},
+		{
+			name:     "kubernetes cgroupfs guaranteed",
+			path:     "/kubepods/pod" + podUID + "/" + containerID,
+			expected: cgroups.ContainerInfo{ContainerID: containerID, PodUID: podUID, QoSClass: cgroups.QoSClassGuaranteed},
+			found:    true,
+		},
+		{
+			name:     "kubernetes cgroupfs cri-o",
+			path:     "/kubepods/besteffort/pod" + podUID + "/crio-" + containerID,
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeCRIO, ContainerID: containerID, PodUID: podUID, QoSClass: cgroups.QoSClassBestEffort},
+			found:    true,
+		},
+		{
+			name:     "kubernetes systemd containerd",
+			path:     "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod" + podUIDSlice + ".slice/cri-containerd-" + containerID + ".scope",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeContainerd, ContainerID: containerID, PodUID: podUID, QoSClass: cgroups.QoSClassBurstable},
+			found:    true,
+		},
+		{
+			name:     "kubernetes systemd cri-o guaranteed",
+			path:     "/kubepods.slice/kubepods-pod" + podUIDSlice + ".slice/crio-" + containerID + ".scope",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeCRIO, ContainerID: containerID, PodUID: podUID, QoSClass: cgroups.QoSClassGuaranteed},
+			found:    true,
+		},
+		{
+			name:     "kubernetes systemd besteffort docker",
+			path:     "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" + podUIDSlice + ".slice/docker-" + containerID + ".scope",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeDocker, ContainerID: containerID, PodUID: podUID, QoSClass: cgroups.QoSClassBestEffort},
+			found:    true,
+		},
+		{
+			name:     "kubernetes systemd cgroup v1 containerd",
+			path:     "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod" + podUIDSlice + ".slice:cri-containerd:" + containerID,
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeContainerd, ContainerID: containerID, PodUID: podUID, QoSClass: cgroups.QoSClassBurstable},
+			found:    true,
+		},
+		{
+			name:     "kubernetes systemd cgroup v1 guaranteed",
+			path:     "/kubepods.slice/kubepods-pod" + podUIDSlice + ".slice:cri-containerd:" + containerID,
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeContainerd, ContainerID: containerID, PodUID: podUID, QoSClass: cgroups.QoSClassGuaranteed},
+			found:    true,
+		},
+		{
+			name:     "kubernetes systemd static pod",
+			path:     "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod" + configHash + ".slice/cri-containerd-" + containerID + ".scope",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeContainerd, ContainerID: containerID, PodUID: configHash, QoSClass: cgroups.QoSClassBurstable},
+			found:    true,
+		},
+		{
+			name:     "kubernetes cgroupfs static pod",
+			path:     "/kubepods/pod" + configHash + "/" + containerID,
+			expected: cgroups.ContainerInfo{ContainerID: containerID, PodUID: configHash, QoSClass: cgroups.QoSClassGuaranteed},
+			found:    true,
+		},
+		{
+			name:     "kind nested in docker",
+			path:     "/docker/1111111111111111111111111111111111111111111111111111111111111111/kubelet/kubepods/burstable/pod" + podUID + "/" + containerID,
+			expected: cgroups.ContainerInfo{ContainerID: containerID, PodUID: podUID, QoSClass: cgroups.QoSClassBurstable},
+			found:    true,
+		},
+		{
+			name:  "cri-o conmon",
+			path:  "/kubepods.slice/kubepods-pod" + podUIDSlice + ".slice/crio-conmon-" + containerID + ".scope",
+			found: false,
+		},
+		{
+			name:     "podman rootful",
+			path:     "/machine.slice/libpod-" + containerID + ".scope",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimePodman, ContainerID: containerID},
+			found:    true,
+		},
+		{
+			name:     "podman rootless",
+			path:     "/user.slice/user-1000.slice/user@1000.service/user.slice/libpod-" + containerID + ".scope/container",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimePodman, ContainerID: containerID},
+			found:    true,
+		},
+		{
+			name:     "podman cgroupfs",
+			path:     "/libpod_parent/libpod-" + containerID,
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimePodman, ContainerID: containerID},
+			found:    true,
+		},
+		{
+			name:  "podman conmon",
+			path:  "/machine.slice/libpod-conmon-" + containerID + ".scope",
+			found: false,
+		},
+		{
+			name:     "nerdctl",
+			path:     "/system.slice/nerdctl-" + containerID + ".scope",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeContainerd, ContainerID: containerID},
+			found:    true,
+		},
+		{
+			name:     "lxc cgroup v1",
+			path:     "/lxc/web01/system.slice/nginx.service",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeLXC, ContainerID: "web01"},
+			found:    true,
+		},
+		{
+			name:     "lxc cgroup v2",
+			path:     "/lxc.payload.web01/init.scope",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeLXC, ContainerID: "web01"},
+			found:    true,
+		},
+		{
+			name:     "systemd-nspawn service",
+			path:     `/machine.slice/systemd-nspawn@build\x2dhost.service/payload/system.slice`,
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeNspawn, ContainerID: "build-host"},
+			found:    true,
+		},
+		{
+			name:     "systemd-nspawn machine scope",
+			path:     `/machine.slice/machine-build\x2dhost.scope/payload`,
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeNspawn, ContainerID: "build-host"},
+			found:    true,
+		},
+		{
+			name:  "libvirt machine",
+			path:  `/machine.slice/machine-qemu\x2d1\x2dvm.scope`,
+			found: false,
+		},
+		{
+			name:     "garden",
+			path:     "/garden/5e6a0b1c-7d1e-4c1a-6f3b-2a9c",
+			expected: cgroups.ContainerInfo{Runtime: cgroups.RuntimeGarden, ContainerID: "5e6a0b1c-7d1e-4c1a-6f3b-2a9c"},
+			found:    true,
+		},
+		{
+			name:  "host service",
+			path:  "/system.slice/sshd.service",
+			found: false,
+		},
+		{
+			name:  "root",
+			path:  "/",
+			found: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			info, found := cgroups.ParsePath(tc.path)
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.expected, info)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	info, found := cgroups.Parse([]string{
+		"/system.slice/containerd.service",
+		"/" + containerID,
+		"/kubepods.slice/kubepods-pod" + podUIDSlice + ".slice/cri-containerd-" + containerID + ".scope",
+	})
+	assert.True(t, found)
+	assert.Equal(t, cgroups.ContainerInfo{
+		Runtime:     cgroups.RuntimeContainerd,
+		ContainerID: containerID,
+		PodUID:      podUID,
+		QoSClass:    cgroups.QoSClassGuaranteed,
+	}, info)
+
+	info, found = cgroups.Parse([]string{"/", "/" + containerID})
+	assert.True(t, found)
+	assert.Equal(t, cgroups.ContainerInfo{ContainerID: containerID}, info)
+
+	_, found = cgroups.Parse([]string{"/", "/user.slice"})
+	assert.False(t, found)
+}
+
+func FuzzParsePath(f *testing.F) {
+	for _, seed := range []string{
+		"/docker/" + containerID,
+		"/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod" + podUIDSlice + ".slice/cri-containerd-" + containerID + ".scope",
+		"/kubepods.slice/kubepods-pod" + podUIDSlice + ".slice:cri-containerd:" + containerID,
+		`/machine.slice/systemd-nspawn@a\x2db.service`,
+		"/lxc.payload.c1",
+		"/garden/abcdefgh",
+		`\x`,
+		"",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		info, found := cgroups.ParsePath(path)
+		if !found {
+			assert.Equal(t, cgroups.ContainerInfo{}, info)
+
+			return
+		}
+
+		assert.NotEmpty(t, info.ContainerID)
+		if info.PodUID != "" {
+			assert.Contains(t, []int{32, 36}, len(info.PodUID))
+			assert.False(t, strings.Contains(info.PodUID, "_"))
+		}
+	})
+}
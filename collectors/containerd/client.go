@@ -0,0 +1,148 @@
+package containerd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"emperror.dev/errors"
+	containers "github.com/containerd/containerd/api/services/containers/v1"
+	images "github.com/containerd/containerd/api/services/images/v1"
+	tasks "github.com/containerd/containerd/api/services/tasks/v1"
+	"github.com/containerd/containerd/api/types/task"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/containerd/errdefs/pkg/errgrpc"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// namespaceHeader is the grpc metadata key containerd reads the namespace of
+// a request from.
+const namespaceHeader = "containerd-namespace"
+
+type ContainerInspectResponse struct {
+	Namespace string
+	Container *containers.Container
+	Image     *images.Image
+	Task      *task.Process
+	Spec      *specs.Spec
+}
+
+type client struct {
+	namespaces []string
+
+	containers containers.ContainersClient
+	images     images.ImagesClient
+	tasks      tasks.TasksClient
+}
+
+// NewClient returns a ContainerInspector talking to the containerd API on
+// socketPath which looks containers up in the given namespaces in order.
+func NewClient(socketPath string, namespaces []string, opts ...grpc.DialOption) (ContainerInspector, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+
+	conn, err := grpc.NewClient("unix://"+strings.TrimPrefix(socketPath, "unix://"), opts...)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not create grpc client")
+	}
+
+	return &client{
+		namespaces: namespaces,
+		containers: containers.NewContainersClient(conn),
+		images:     images.NewImagesClient(conn),
+		tasks:      tasks.NewTasksClient(conn),
+	}, nil
+}
+
+func (c *client) ContainerInspect(ctx context.Context, containerID string) (ContainerInspectResponse, error) {
+	for _, namespace := range c.namespaces {
+		nsCtx := metadata.AppendToOutgoingContext(ctx, namespaceHeader, namespace)
+
+		resp, err := c.containers.Get(nsCtx, &containers.GetContainerRequest{ID: containerID})
+		if err != nil {
+			if err = errgrpc.ToNative(err); cerrdefs.IsNotFound(err) {
+				continue
+			}
+
+			return ContainerInspectResponse{}, errors.WrapIfWithDetails(err, "could not get container", "namespace", namespace, "containerID", containerID)
+		}
+
+		return c.inspect(nsCtx, namespace, resp.GetContainer())
+	}
+
+	return ContainerInspectResponse{}, errors.WithDetails(cerrdefs.ErrNotFound, "containerID", containerID)
+}
+
+func (c *client) inspect(ctx context.Context, namespace string, container *containers.Container) (ContainerInspectResponse, error) {
+	resp := ContainerInspectResponse{
+		Namespace: namespace,
+		Container: container,
+	}
+
+	if container.GetImage() != "" {
+		image, err := c.images.Get(ctx, &images.GetImageRequest{Name: container.GetImage()})
+		if err := errgrpc.ToNative(err); err != nil && !cerrdefs.IsNotFound(err) {
+			return resp, errors.WrapIfWithDetails(err, "could not get image", "namespace", namespace, "image", container.GetImage())
+		}
+		resp.Image = image.GetImage()
+	}
+
+	t, err := c.tasks.Get(ctx, &tasks.GetRequest{ContainerID: container.GetID()})
+	if err := errgrpc.ToNative(err); err != nil && !cerrdefs.IsNotFound(err) {
+		return resp, errors.WrapIfWithDetails(err, "could not get task", "namespace", namespace, "containerID", container.GetID())
+	}
+	resp.Task = t.GetProcess()
+
+	// the spec is stored as the JSON encoded OCI runtime spec
+	if value := container.GetSpec().GetValue(); len(value) > 0 {
+		var spec specs.Spec
+		if err := json.Unmarshal(value, &spec); err != nil {
+			return resp, errors.WrapIfWithDetails(err, "could not decode container spec", "namespace", namespace, "containerID", container.GetID())
+		}
+		resp.Spec = &spec
+	}
+
+	return resp, nil
+}
+
+// GetContainerIDForPID returns the container of the task owning pid, either
+// as its init process or as one of the processes listed by the shim.
+func (c *client) GetContainerIDForPID(ctx context.Context, pid int) (string, error) {
+	for _, namespace := range c.namespaces {
+		nsCtx := metadata.AppendToOutgoingContext(ctx, namespaceHeader, namespace)
+
+		resp, err := c.tasks.List(nsCtx, &tasks.ListTasksRequest{})
+		if err != nil {
+			return "", errors.WrapIfWithDetails(errgrpc.ToNative(err), "could not list tasks", "namespace", namespace)
+		}
+
+		for _, t := range resp.GetTasks() {
+			if int(t.GetPid()) == pid {
+				return t.GetContainerID(), nil
+			}
+		}
+
+		for _, t := range resp.GetTasks() {
+			if t.GetStatus() != task.Status_RUNNING && t.GetStatus() != task.Status_PAUSED {
+				continue
+			}
+
+			pids, err := c.tasks.ListPids(nsCtx, &tasks.ListPidsRequest{ContainerID: t.GetContainerID()})
+			if err != nil {
+				continue
+			}
+
+			for _, p := range pids.GetProcesses() {
+				if int(p.GetPid()) == pid {
+					return t.GetContainerID(), nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,341 @@
+package containerd
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+
+	"emperror.dev/errors"
+	cerrdefs "github.com/containerd/errdefs"
+	"google.golang.org/grpc"
+
+	"github.com/gezacorp/metadatax"
+)
+
+const (
+	name = "containerd"
+
+	defaultSocketPath = "unix:///run/containerd/containerd.sock"
+
+	redactedValue = "<redacted>"
+)
+
+var (
+	ContainerIDNotFoundError = errors.Sentinel("could not find container id for pid")
+
+	defaultNamespaces = []string{"k8s.io", "moby", "default"}
+)
+
+type collector struct {
+	socketPath         string
+	namespaces         []string
+	grpcDialOpts       []grpc.DialOption
+	containerInspector ContainerInspector
+	containerIDGetter  ContainerIDGetter
+	redactedEnvs       []string
+
+	mdContainerInitFunc func() metadatax.MetadataContainer
+	skipOnSoftError     bool
+	taskPIDLookup       bool
+	hasContainerd       *bool
+}
+
+type ContainerInspector interface {
+	ContainerInspect(ctx context.Context, containerID string) (ContainerInspectResponse, error)
+	GetContainerIDForPID(ctx context.Context, pid int) (string, error)
+}
+
+type ContainerIDGetter interface {
+	GetContainerIDFromPID(pid int) (string, error)
+}
+
+type CollectorOption func(*collector)
+
+func WithGRPCDialOpts(opts ...grpc.DialOption) CollectorOption {
+	return func(c *collector) {
+		c.grpcDialOpts = opts
+	}
+}
+
+func WithSocketPath(socketPath string) CollectorOption {
+	return func(c *collector) {
+		c.socketPath = socketPath
+	}
+}
+
+func WithNamespaces(namespaces ...string) CollectorOption {
+	return func(c *collector) {
+		c.namespaces = namespaces
+	}
+}
+
+func WithContainerInspector(inspector ContainerInspector) CollectorOption {
+	return func(c *collector) {
+		c.containerInspector = inspector
+	}
+}
+
+func WithContainerIDGetter(containerIDGetter ContainerIDGetter) CollectorOption {
+	return func(c *collector) {
+		c.containerIDGetter = containerIDGetter
+	}
+}
+
+// WithRedactedEnvs hides the values of the environment variables whose
+// names match any of the given shell patterns, e.g. "*_PASSWORD".
+func WithRedactedEnvs(patterns ...string) CollectorOption {
+	return func(c *collector) {
+		for _, p := range patterns {
+			c.redactedEnvs = append(c.redactedEnvs, strings.ToUpper(p))
+		}
+	}
+}
+
+func CollectorWithMetadataContainerInitFunc(fn func() metadatax.MetadataContainer) CollectorOption {
+	return func(c *collector) {
+		c.mdContainerInitFunc = fn
+	}
+}
+
+// WithTaskPIDLookup resolves processes whose cgroup paths do not name a
+// container, like the ones of containers started by ctr, by listing the
+// processes of every containerd task. It costs a request per task for every
+// such process, including the ones not running in a container at all.
+func WithTaskPIDLookup() CollectorOption {
+	return func(c *collector) {
+		c.taskPIDLookup = true
+	}
+}
+
+func WithSkipOnSoftError() CollectorOption {
+	return func(c *collector) {
+		c.skipOnSoftError = true
+	}
+}
+
+func New(opts ...CollectorOption) metadatax.Collector {
+	c := &collector{}
+
+	for _, f := range opts {
+		f(c)
+	}
+
+	if c.socketPath == "" {
+		if address := os.Getenv("CONTAINERD_ADDRESS"); address != "" {
+			c.socketPath = address
+		}
+	}
+
+	if c.socketPath == "" {
+		c.socketPath = defaultSocketPath
+	}
+
+	if len(c.namespaces) == 0 {
+		c.namespaces = defaultNamespaces
+	}
+
+	if c.containerIDGetter == nil {
+		c.containerIDGetter = c
+	}
+
+	if c.mdContainerInitFunc == nil {
+		c.mdContainerInitFunc = func() metadatax.MetadataContainer {
+			return metadatax.New(metadatax.WithPrefix(name))
+		}
+	}
+
+	return c
+}
+
+func (c *collector) HasContainerd() bool {
+	if c.hasContainerd != nil {
+		return *c.hasContainerd
+	}
+
+	ret := c.isSocketPathExists(c.socketPath)
+	c.hasContainerd = &ret
+
+	return ret
+}
+
+func (c *collector) isSocketPathExists(path string) bool {
+	path = strings.TrimPrefix(path, "unix://")
+
+	if _, err := os.Open(path); errors.Is(err, os.ErrPermission) {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return (info.Mode() & os.ModeSocket) != 0
+}
+
+func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContainer, error) {
+	md := c.mdContainerInitFunc()
+
+	if c.containerInspector == nil {
+		if !c.HasContainerd() {
+			return md, nil
+		}
+
+		var err error
+		if c.containerInspector, err = NewClient(c.socketPath, c.namespaces, c.grpcDialOpts...); err != nil {
+			return nil, errors.WrapIf(err, "could not get containerd client")
+		}
+	}
+
+	pid, found := metadatax.PIDFromContext(ctx)
+	if !found {
+		return nil, metadatax.PIDNotFoundError
+	}
+
+	containerID, err := c.containerIDGetter.GetContainerIDFromPID(int(pid))
+	if err != nil {
+		if c.skipOnSoftError {
+			return md, nil
+		}
+
+		return nil, errors.WrapIfWithDetails(err, "could not get cgroups from pid", "pid", pid)
+	}
+
+	// containers started by ctr use arbitrary ids in their cgroup paths
+	if containerID == "" && c.taskPIDLookup {
+		if containerID, err = c.containerInspector.GetContainerIDForPID(ctx, int(pid)); err != nil {
+			if c.skipOnSoftError {
+				return md, nil
+			}
+
+			return nil, errors.WrapIfWithDetails(err, "could not list containerd tasks", "pid", pid)
+		}
+	}
+
+	if containerID == "" {
+		if c.skipOnSoftError {
+			return md, nil
+		}
+
+		return nil, errors.WithDetails(ContainerIDNotFoundError, "pid", pid)
+	}
+
+	resp, err := c.containerInspector.ContainerInspect(ctx, containerID)
+	if c.skipOnSoftError && cerrdefs.IsNotFound(err) {
+		return md, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	getters := []func(ContainerInspectResponse, metadatax.MetadataContainer){
+		c.base,
+		c.labels,
+		c.envs,
+		c.image,
+		c.runtime,
+		c.task,
+	}
+
+	for _, f := range getters {
+		f(resp, md)
+	}
+
+	return md, nil
+}
+
+func (c *collector) GetContainerIDFromPID(pid int) (string, error) {
+	cgroups, err := GetCgroupsForPID(pid)
+	if err != nil {
+		return "", errors.WithStackIf(err)
+	}
+
+	return GetContainerIDFromCgroups(cgroups), nil
+}
+
+func (c *collector) base(resp ContainerInspectResponse, md metadatax.MetadataContainer) {
+	md.AddLabel("id", resp.Container.GetID())
+	md.AddLabel("namespace", resp.Namespace)
+	md.AddLabel("snapshotter", resp.Container.GetSnapshotter())
+}
+
+func (c *collector) labels(resp ContainerInspectResponse, md metadatax.MetadataContainer) {
+	lmd := md.Segment("label")
+	for k, v := range resp.Container.GetLabels() {
+		lmd.AddLabel(k, v)
+	}
+}
+
+func (c *collector) envs(resp ContainerInspectResponse, md metadatax.MetadataContainer) {
+	if resp.Spec == nil || resp.Spec.Process == nil {
+		return
+	}
+
+	emd := md.Segment("env")
+	for _, env := range resp.Spec.Process.Env {
+		k, v, found := strings.Cut(env, "=")
+		if !found {
+			continue
+		}
+
+		k = strings.ToUpper(k)
+		if c.isRedactedEnv(k) {
+			v = redactedValue
+		}
+
+		emd.AddLabel(k, v)
+	}
+}
+
+func (c *collector) isRedactedEnv(key string) bool {
+	for _, p := range c.redactedEnvs {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *collector) image(resp ContainerInspectResponse, md metadatax.MetadataContainer) {
+	md.Segment("image").
+		AddLabel("name", resp.Container.GetImage()).
+		AddLabel("digest", resp.Image.GetTarget().GetDigest())
+}
+
+func (c *collector) runtime(resp ContainerInspectResponse, md metadatax.MetadataContainer) {
+	runtimeName := resp.Container.GetRuntime().GetName()
+
+	md.Segment("runtime").
+		AddLabel("name", runtimeName).
+		AddLabel("type", RuntimeType(runtimeName))
+}
+
+func (c *collector) task(resp ContainerInspectResponse, md metadatax.MetadataContainer) {
+	if resp.Task == nil {
+		return
+	}
+
+	md.Segment("task").
+		AddLabel("status", strings.ToLower(resp.Task.GetStatus().String()))
+}
+
+// RuntimeType returns the low level runtime of a containerd shim name like
+// io.containerd.runc.v2 or io.containerd.runsc.v1.
+func RuntimeType(runtimeName string) string {
+	shim := runtimeName
+	if parts := strings.Split(runtimeName, "."); len(parts) == 4 && parts[0] == "io" {
+		shim = parts[2]
+	}
+
+	switch {
+	case shim == "runsc" || strings.Contains(shim, "gvisor"):
+		return "gvisor"
+	case strings.HasPrefix(shim, "kata"):
+		return "kata"
+	default:
+		return shim
+	}
+}
@@ -0,0 +1,368 @@
+package containerd_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+
+	containers "github.com/containerd/containerd/api/services/containers/v1"
+	images "github.com/containerd/containerd/api/services/images/v1"
+	tasks "github.com/containerd/containerd/api/services/tasks/v1"
+	"github.com/containerd/containerd/api/types"
+	"github.com/containerd/containerd/api/types/task"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/gezacorp/metadatax"
+	"github.com/gezacorp/metadatax/collectors/containerd"
+)
+
+const (
+	podContainerID = "8f2c6c8b3b0b4c0f9d6c1b7b1f0d4d2b5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b"
+	imageDigest    = "sha256:c20060033e06f882b0fbe2db7d974d72e0887a3be5e554efdb0dcf8d53512647"
+)
+
+type containerIDGetter struct {
+	id string
+}
+
+func (g *containerIDGetter) GetContainerIDFromPID(pid int) (string, error) {
+	return g.id, nil
+}
+
+type namespacedStore struct {
+	containers map[string]*containers.Container
+	images     map[string]*images.Image
+	tasks      map[string]*task.Process
+	pids       map[string][]uint32
+}
+
+type fakeStore struct {
+	namespaces map[string]namespacedStore
+}
+
+func (s *fakeStore) store(ctx context.Context) (namespacedStore, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ns := md.Get("containerd-namespace"); len(ns) == 1 {
+		return s.namespaces[ns[0]], nil
+	}
+
+	return namespacedStore{}, status.Error(codes.FailedPrecondition, "namespace is required")
+}
+
+type fakeContainersServer struct {
+	containers.UnimplementedContainersServer
+	*fakeStore
+}
+
+func (s fakeContainersServer) Get(ctx context.Context, req *containers.GetContainerRequest) (*containers.GetContainerResponse, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := store.containers[req.GetID()]; ok {
+		return &containers.GetContainerResponse{Container: c}, nil
+	}
+
+	return nil, status.Errorf(codes.NotFound, "container %q: not found", req.GetID())
+}
+
+type fakeImagesServer struct {
+	images.UnimplementedImagesServer
+	*fakeStore
+}
+
+func (s fakeImagesServer) Get(ctx context.Context, req *images.GetImageRequest) (*images.GetImageResponse, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if i, ok := store.images[req.GetName()]; ok {
+		return &images.GetImageResponse{Image: i}, nil
+	}
+
+	return nil, status.Errorf(codes.NotFound, "image %q: not found", req.GetName())
+}
+
+type fakeTasksServer struct {
+	tasks.UnimplementedTasksServer
+	*fakeStore
+}
+
+func (s fakeTasksServer) Get(ctx context.Context, req *tasks.GetRequest) (*tasks.GetResponse, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := store.tasks[req.GetContainerID()]; ok {
+		return &tasks.GetResponse{Process: t}, nil
+	}
+
+	return nil, status.Errorf(codes.NotFound, "task %q: not found", req.GetContainerID())
+}
+
+func (s fakeTasksServer) List(ctx context.Context, req *tasks.ListTasksRequest) (*tasks.ListTasksResponse, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &tasks.ListTasksResponse{}
+	for _, t := range store.tasks {
+		resp.Tasks = append(resp.Tasks, t)
+	}
+
+	return resp, nil
+}
+
+func (s fakeTasksServer) ListPids(ctx context.Context, req *tasks.ListPidsRequest) (*tasks.ListPidsResponse, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &tasks.ListPidsResponse{}
+	for _, pid := range store.pids[req.GetContainerID()] {
+		resp.Processes = append(resp.Processes, &task.ProcessInfo{Pid: pid})
+	}
+
+	return resp, nil
+}
+
+func specAny(t *testing.T, env ...string) *anypb.Any {
+	t.Helper()
+
+	value, err := json.Marshal(specs.Spec{
+		Version: specs.Version,
+		Process: &specs.Process{Env: env},
+	})
+	require.NoError(t, err)
+
+	return &anypb.Any{
+		TypeUrl: "types.containerd.io/opencontainers/runtime-spec/1/Spec",
+		Value:   value,
+	}
+}
+
+func startFakeServer(t *testing.T) string {
+	t.Helper()
+
+	fake := &fakeStore{
+		namespaces: map[string]namespacedStore{
+			"k8s.io": {
+				containers: map[string]*containers.Container{
+					podContainerID: {
+						ID: podContainerID,
+						Labels: map[string]string{
+							"io.kubernetes.container.name": "nginx",
+							"io.kubernetes.pod.namespace":  "default",
+						},
+						Image:       "docker.io/library/nginx:1.25.3",
+						Runtime:     &containers.Container_Runtime{Name: "io.containerd.kata.v2"},
+						Spec:        specAny(t, "PATH=/usr/local/sbin:/usr/local/bin", "DB_PASSWORD=secret", "nginx_version=1.25.3"),
+						Snapshotter: "overlayfs",
+					},
+				},
+				images: map[string]*images.Image{
+					"docker.io/library/nginx:1.25.3": {
+						Name: "docker.io/library/nginx:1.25.3",
+						Target: &types.Descriptor{
+							MediaType: "application/vnd.oci.image.index.v1+json",
+							Digest:    imageDigest,
+						},
+					},
+				},
+				tasks: map[string]*task.Process{
+					podContainerID: {ContainerID: podContainerID, Pid: 4242, Status: task.Status_RUNNING},
+				},
+			},
+			"default": {
+				containers: map[string]*containers.Container{
+					"redis": {
+						ID:          "redis",
+						Image:       "docker.io/library/redis:7",
+						Runtime:     &containers.Container_Runtime{Name: "io.containerd.runsc.v1"},
+						Snapshotter: "native",
+					},
+				},
+				tasks: map[string]*task.Process{
+					"redis": {ContainerID: "redis", Pid: 5000, Status: task.Status_PAUSED},
+				},
+				pids: map[string][]uint32{
+					"redis": {5000, 5001},
+				},
+			},
+		},
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "containerd.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	containers.RegisterContainersServer(server, fakeContainersServer{fakeStore: fake})
+	images.RegisterImagesServer(server, fakeImagesServer{fakeStore: fake})
+	tasks.RegisterTasksServer(server, fakeTasksServer{fakeStore: fake})
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	return socketPath
+}
+
+func TestGetMetadata(t *testing.T) {
+	t.Parallel()
+
+	socketPath := startFakeServer(t)
+
+	collector := containerd.New(
+		containerd.WithSocketPath("unix://"+socketPath),
+		containerd.WithContainerIDGetter(&containerIDGetter{id: podContainerID}),
+		containerd.WithRedactedEnvs("*_password"),
+	)
+
+	expectedLabels := map[string][]string{
+		"containerd:env:DB_PASSWORD":                    {"<redacted>"},
+		"containerd:env:NGINX_VERSION":                  {"1.25.3"},
+		"containerd:env:PATH":                           {"/usr/local/sbin:/usr/local/bin"},
+		"containerd:id":                                 {podContainerID},
+		"containerd:image:digest":                       {imageDigest},
+		"containerd:image:name":                         {"docker.io/library/nginx:1.25.3"},
+		"containerd:label:io.kubernetes.container.name": {"nginx"},
+		"containerd:label:io.kubernetes.pod.namespace":  {"default"},
+		"containerd:namespace":                          {"k8s.io"},
+		"containerd:runtime:name":                       {"io.containerd.kata.v2"},
+		"containerd:runtime:type":                       {"kata"},
+		"containerd:snapshotter":                        {"overlayfs"},
+		"containerd:task:status":                        {"running"},
+	}
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 4242))
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedLabels, map[string][]string(md.GetLabels()))
+}
+
+func TestGetMetadataByTaskPID(t *testing.T) {
+	t.Parallel()
+
+	socketPath := startFakeServer(t)
+
+	collector := containerd.New(
+		containerd.WithSocketPath(socketPath),
+		containerd.WithContainerIDGetter(&containerIDGetter{}),
+	)
+
+	// tasks are only scanned on request
+	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 5001))
+	assert.ErrorIs(t, err, containerd.ContainerIDNotFoundError)
+
+	collector = containerd.New(
+		containerd.WithSocketPath(socketPath),
+		containerd.WithContainerIDGetter(&containerIDGetter{}),
+		containerd.WithTaskPIDLookup(),
+	)
+
+	expectedLabels := map[string][]string{
+		"containerd:id":           {"redis"},
+		"containerd:image:name":   {"docker.io/library/redis:7"},
+		"containerd:namespace":    {"default"},
+		"containerd:runtime:name": {"io.containerd.runsc.v1"},
+		"containerd:runtime:type": {"gvisor"},
+		"containerd:snapshotter":  {"native"},
+		"containerd:task:status":  {"paused"},
+	}
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 5001))
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedLabels, map[string][]string(md.GetLabels()))
+}
+
+func TestGetMetadataTaskPIDLookupSoftError(t *testing.T) {
+	t.Parallel()
+
+	// a server without the tasks service fails every task listing
+	socketPath := filepath.Join(t.TempDir(), "containerd.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	collector := containerd.New(
+		containerd.WithSocketPath(socketPath),
+		containerd.WithContainerIDGetter(&containerIDGetter{}),
+		containerd.WithTaskPIDLookup(),
+	)
+
+	_, err = collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 5001))
+	assert.Error(t, err)
+
+	collector = containerd.New(
+		containerd.WithSocketPath(socketPath),
+		containerd.WithContainerIDGetter(&containerIDGetter{}),
+		containerd.WithTaskPIDLookup(),
+		containerd.WithSkipOnSoftError(),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 5001))
+	require.NoError(t, err)
+	assert.Empty(t, md.GetLabels())
+}
+
+func TestGetMetadataNotFound(t *testing.T) {
+	t.Parallel()
+
+	socketPath := startFakeServer(t)
+
+	collector := containerd.New(
+		containerd.WithSocketPath(socketPath),
+		containerd.WithContainerIDGetter(&containerIDGetter{id: "missing"}),
+	)
+
+	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Error(t, err)
+
+	collector = containerd.New(
+		containerd.WithSocketPath(socketPath),
+		containerd.WithContainerIDGetter(&containerIDGetter{}),
+		containerd.WithSkipOnSoftError(),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	require.NoError(t, err)
+	assert.Empty(t, md.GetLabels())
+}
+
+func TestRuntimeType(t *testing.T) {
+	t.Parallel()
+
+	for runtimeName, expected := range map[string]string{
+		"io.containerd.runc.v2":      "runc",
+		"io.containerd.runc.v1":      "runc",
+		"io.containerd.kata-qemu.v2": "kata",
+		"io.containerd.runsc.v1":     "gvisor",
+		"io.containerd.runhcs.v1":    "runhcs",
+		"io.containerd.crun.v2":      "crun",
+		"":                           "",
+	} {
+		assert.Equal(t, expected, containerd.RuntimeType(runtimeName), runtimeName)
+	}
+}
@@ -0,0 +1,334 @@
+package cri
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/gezacorp/metadatax"
+)
+
+const (
+	name = "cri"
+)
+
+var (
+	ContainerIDNotFoundError = errors.Sentinel("could not find container id for pid")
+	ContainerNotFoundError   = errors.Sentinel("could not find container")
+
+	defaultSocketPaths = []string{
+		"unix:///run/containerd/containerd.sock",
+		"unix:///run/crio/crio.sock",
+		"unix:///var/run/cri-dockerd.sock",
+	}
+)
+
+type collector struct {
+	socketPath        string
+	grpcDialOpts      []grpc.DialOption
+	runtimeClient     RuntimeServiceClient
+	containerIDGetter ContainerIDGetter
+
+	mdContainerInitFunc func() metadatax.MetadataContainer
+	skipOnSoftError     bool
+	hasCRI              *bool
+}
+
+// RuntimeServiceClient is the part of the CRI runtime service the collector
+// uses; it is implemented by runtimeapi.RuntimeServiceClient.
+type RuntimeServiceClient interface {
+	ListContainers(ctx context.Context, in *runtimeapi.ListContainersRequest, opts ...grpc.CallOption) (*runtimeapi.ListContainersResponse, error)
+	ContainerStatus(ctx context.Context, in *runtimeapi.ContainerStatusRequest, opts ...grpc.CallOption) (*runtimeapi.ContainerStatusResponse, error)
+	PodSandboxStatus(ctx context.Context, in *runtimeapi.PodSandboxStatusRequest, opts ...grpc.CallOption) (*runtimeapi.PodSandboxStatusResponse, error)
+}
+
+type ContainerIDGetter interface {
+	GetContainerIDFromPID(pid int) (string, error)
+}
+
+type CollectorOption func(*collector)
+
+func WithGRPCDialOpts(opts ...grpc.DialOption) CollectorOption {
+	return func(c *collector) {
+		c.grpcDialOpts = opts
+	}
+}
+
+func WithSocketPath(socketPath string) CollectorOption {
+	return func(c *collector) {
+		c.socketPath = socketPath
+	}
+}
+
+func WithRuntimeServiceClient(client RuntimeServiceClient) CollectorOption {
+	return func(c *collector) {
+		c.runtimeClient = client
+	}
+}
+
+func WithContainerIDGetter(containerIDGetter ContainerIDGetter) CollectorOption {
+	return func(c *collector) {
+		c.containerIDGetter = containerIDGetter
+	}
+}
+
+func CollectorWithMetadataContainerInitFunc(fn func() metadatax.MetadataContainer) CollectorOption {
+	return func(c *collector) {
+		c.mdContainerInitFunc = fn
+	}
+}
+
+func WithSkipOnSoftError() CollectorOption {
+	return func(c *collector) {
+		c.skipOnSoftError = true
+	}
+}
+
+func New(opts ...CollectorOption) metadatax.Collector {
+	c := &collector{}
+
+	for _, f := range opts {
+		f(c)
+	}
+
+	if c.socketPath == "" {
+		c.socketPath = os.Getenv("CONTAINER_RUNTIME_ENDPOINT")
+	}
+
+	if c.socketPath == "" {
+		for _, p := range defaultSocketPaths {
+			if c.isSocketPathExists(p) {
+				c.socketPath = p
+
+				break
+			}
+		}
+	}
+
+	if c.containerIDGetter == nil {
+		c.containerIDGetter = c
+	}
+
+	if c.mdContainerInitFunc == nil {
+		c.mdContainerInitFunc = func() metadatax.MetadataContainer {
+			return metadatax.New(metadatax.WithPrefix(name))
+		}
+	}
+
+	return c
+}
+
+func (c *collector) HasCRI() bool {
+	if c.hasCRI != nil {
+		return *c.hasCRI
+	}
+
+	ret := c.socketPath != "" && c.isSocketPathExists(c.socketPath)
+	c.hasCRI = &ret
+
+	return ret
+}
+
+func (c *collector) isSocketPathExists(path string) bool {
+	path = strings.TrimPrefix(path, "unix://")
+
+	if _, err := os.Open(path); errors.Is(err, os.ErrPermission) {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return (info.Mode() & os.ModeSocket) != 0
+}
+
+func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContainer, error) {
+	md := c.mdContainerInitFunc()
+
+	if c.runtimeClient == nil {
+		if !c.HasCRI() {
+			return md, nil
+		}
+
+		var err error
+		if c.runtimeClient, err = c.getRuntimeClient(); err != nil {
+			return nil, errors.WrapIf(err, "could not get cri client")
+		}
+	}
+
+	pid, found := metadatax.PIDFromContext(ctx)
+	if !found {
+		return nil, metadatax.PIDNotFoundError
+	}
+
+	containerID, err := c.containerIDGetter.GetContainerIDFromPID(int(pid))
+	if err != nil {
+		if c.skipOnSoftError {
+			return md, nil
+		}
+
+		return nil, errors.WrapIfWithDetails(err, "could not get cgroups from pid", "pid", pid)
+	}
+
+	if containerID == "" {
+		if c.skipOnSoftError {
+			return md, nil
+		}
+
+		return nil, errors.WithDetails(ContainerIDNotFoundError, "pid", pid)
+	}
+
+	containerStatus, podStatus, err := c.getStatuses(ctx, containerID)
+	if c.skipOnSoftError && errors.Is(err, ContainerNotFoundError) {
+		return md, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.container(containerStatus, md)
+	c.image(containerStatus, md)
+	c.mounts(containerStatus, md)
+	c.pod(podStatus, md)
+
+	runtimeHandler := podStatus.GetRuntimeHandler()
+	if runtimeHandler == "" {
+		runtimeHandler = containerStatus.GetImage().GetRuntimeHandler()
+	}
+	md.AddLabel("runtime-handler", runtimeHandler)
+
+	return md, nil
+}
+
+func (c *collector) getStatuses(ctx context.Context, containerID string) (*runtimeapi.ContainerStatus, *runtimeapi.PodSandboxStatus, error) {
+	// the container status does not reference the sandbox
+	containers, err := c.runtimeClient.ListContainers(ctx, &runtimeapi.ListContainersRequest{
+		Filter: &runtimeapi.ContainerFilter{Id: containerID},
+	})
+	if err != nil {
+		return nil, nil, errors.WrapIfWithDetails(err, "could not list containers", "containerID", containerID)
+	}
+
+	if len(containers.GetContainers()) == 0 {
+		return nil, nil, errors.WithDetails(ContainerNotFoundError, "containerID", containerID)
+	}
+
+	containerStatus, err := c.runtimeClient.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: containerID})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil, errors.WithDetails(ContainerNotFoundError, "containerID", containerID)
+	}
+	if err != nil {
+		return nil, nil, errors.WrapIfWithDetails(err, "could not get container status", "containerID", containerID)
+	}
+
+	podSandboxID := containers.GetContainers()[0].GetPodSandboxId()
+	podStatus, err := c.runtimeClient.PodSandboxStatus(ctx, &runtimeapi.PodSandboxStatusRequest{PodSandboxId: podSandboxID})
+	if err != nil {
+		return nil, nil, errors.WrapIfWithDetails(err, "could not get pod sandbox status", "podSandboxID", podSandboxID)
+	}
+
+	return containerStatus.GetStatus(), podStatus.GetStatus(), nil
+}
+
+func (c *collector) GetContainerIDFromPID(pid int) (string, error) {
+	cgroups, err := GetCgroupsForPID(pid)
+	if err != nil {
+		return "", errors.WithStackIf(err)
+	}
+
+	return GetContainerIDFromCgroups(cgroups), nil
+}
+
+func (c *collector) container(status *runtimeapi.ContainerStatus, md metadatax.MetadataContainer) {
+	cmd := md.Segment("container")
+	cmd.AddLabel("id", status.GetId())
+	cmd.AddLabel("name", status.GetMetadata().GetName())
+	cmd.AddLabel("attempt", strconv.FormatUint(uint64(status.GetMetadata().GetAttempt()), 10))
+	cmd.AddLabel("state", strings.ToLower(strings.TrimPrefix(status.GetState().String(), "CONTAINER_")))
+
+	lmd := cmd.Segment("label")
+	for k, v := range status.GetLabels() {
+		lmd.AddLabel(k, v)
+	}
+
+	amd := cmd.Segment("annotation")
+	for k, v := range status.GetAnnotations() {
+		amd.AddLabel(k, v)
+	}
+}
+
+func (c *collector) image(status *runtimeapi.ContainerStatus, md metadatax.MetadataContainer) {
+	md.Segment("image").
+		AddLabel("name", status.GetImage().GetImage()).
+		AddLabel("user-specified", status.GetImage().GetUserSpecifiedImage()).
+		AddLabel("ref", status.GetImageRef()).
+		AddLabel("id", status.GetImageId())
+}
+
+// mounts emits host-path:container-path:mode entries like docker's -v flag.
+func (c *collector) mounts(status *runtimeapi.ContainerStatus, md metadatax.MetadataContainer) {
+	for _, mount := range status.GetMounts() {
+		mode := "rw"
+		if mount.GetReadonly() {
+			mode = "ro"
+		}
+
+		md.AddLabel("mount", mount.GetHostPath()+":"+mount.GetContainerPath()+":"+mode)
+	}
+}
+
+func (c *collector) pod(status *runtimeapi.PodSandboxStatus, md metadatax.MetadataContainer) {
+	pmd := md.Segment("pod")
+	pmd.AddLabel("id", status.GetId())
+	pmd.AddLabel("name", status.GetMetadata().GetName())
+	pmd.AddLabel("namespace", status.GetMetadata().GetNamespace())
+	pmd.AddLabel("uid", status.GetMetadata().GetUid())
+	pmd.AddLabel("attempt", strconv.FormatUint(uint64(status.GetMetadata().GetAttempt()), 10))
+	pmd.AddLabel("state", strings.ToLower(strings.TrimPrefix(status.GetState().String(), "SANDBOX_")))
+
+	pmd.AddLabel("ip", status.GetNetwork().GetIp())
+	for _, ip := range status.GetNetwork().GetAdditionalIps() {
+		pmd.AddLabel("ip", ip.GetIp())
+	}
+
+	if options := status.GetLinux().GetNamespaces().GetOptions(); options != nil {
+		pmd.Segment("namespace-mode").
+			AddLabel("network", strings.ToLower(options.GetNetwork().String())).
+			AddLabel("pid", strings.ToLower(options.GetPid().String())).
+			AddLabel("ipc", strings.ToLower(options.GetIpc().String()))
+	}
+
+	lmd := pmd.Segment("label")
+	for k, v := range status.GetLabels() {
+		lmd.AddLabel(k, v)
+	}
+
+	amd := pmd.Segment("annotation")
+	for k, v := range status.GetAnnotations() {
+		amd.AddLabel(k, v)
+	}
+}
+
+func (c *collector) getRuntimeClient() (RuntimeServiceClient, error) {
+	opts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, c.grpcDialOpts...)
+
+	conn, err := grpc.NewClient("unix://"+strings.TrimPrefix(c.socketPath, "unix://"), opts...)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not create grpc client")
+	}
+
+	return runtimeapi.NewRuntimeServiceClient(conn), nil
+}
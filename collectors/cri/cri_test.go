@@ -0,0 +1,207 @@
+package cri_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/gezacorp/metadatax"
+	"github.com/gezacorp/metadatax/collectors/cri"
+)
+
+const (
+	containerID  = "8f2c6c8b3b0b4c0f9d6c1b7b1f0d4d2b5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b"
+	podSandboxID = "0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b1a0f9e8d7c6b5a4f3e2d1c0b9a"
+	imageRef     = "docker.io/library/nginx@sha256:c20060033e06f882b0fbe2db7d974d72e0887a3be5e554efdb0dcf8d53512647"
+)
+
+type containerIDGetter struct {
+	id string
+}
+
+func (g *containerIDGetter) GetContainerIDFromPID(pid int) (string, error) {
+	return g.id, nil
+}
+
+type fakeRuntimeServer struct {
+	runtimeapi.UnimplementedRuntimeServiceServer
+}
+
+func (s *fakeRuntimeServer) ListContainers(ctx context.Context, req *runtimeapi.ListContainersRequest) (*runtimeapi.ListContainersResponse, error) {
+	resp := &runtimeapi.ListContainersResponse{}
+	if req.GetFilter().GetId() == containerID {
+		resp.Containers = append(resp.Containers, &runtimeapi.Container{
+			Id:           containerID,
+			PodSandboxId: podSandboxID,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *fakeRuntimeServer) ContainerStatus(ctx context.Context, req *runtimeapi.ContainerStatusRequest) (*runtimeapi.ContainerStatusResponse, error) {
+	if req.GetContainerId() != containerID {
+		return nil, status.Errorf(codes.NotFound, "container %q not found", req.GetContainerId())
+	}
+
+	return &runtimeapi.ContainerStatusResponse{
+		Status: &runtimeapi.ContainerStatus{
+			Id:       containerID,
+			Metadata: &runtimeapi.ContainerMetadata{Name: "nginx", Attempt: 2},
+			State:    runtimeapi.ContainerState_CONTAINER_RUNNING,
+			Image: &runtimeapi.ImageSpec{
+				Image:              "docker.io/library/nginx:1.25.3",
+				UserSpecifiedImage: "nginx:1.25.3",
+			},
+			ImageRef: imageRef,
+			Labels: map[string]string{
+				"io.kubernetes.container.name": "nginx",
+			},
+			Annotations: map[string]string{
+				"io.kubernetes.container.restartCount": "2",
+			},
+			Mounts: []*runtimeapi.Mount{
+				{
+					ContainerPath: "/var/run/secrets/kubernetes.io/serviceaccount",
+					HostPath:      "/var/lib/kubelet/pods/5831c41b/volumes/kubernetes.io~projected/kube-api-access",
+					Readonly:      true,
+				},
+				{
+					ContainerPath: "/data",
+					HostPath:      "/mnt/data",
+				},
+			},
+		},
+	}, nil
+}
+
+func (s *fakeRuntimeServer) PodSandboxStatus(ctx context.Context, req *runtimeapi.PodSandboxStatusRequest) (*runtimeapi.PodSandboxStatusResponse, error) {
+	if req.GetPodSandboxId() != podSandboxID {
+		return nil, status.Errorf(codes.NotFound, "pod sandbox %q not found", req.GetPodSandboxId())
+	}
+
+	return &runtimeapi.PodSandboxStatusResponse{
+		Status: &runtimeapi.PodSandboxStatus{
+			Id: podSandboxID,
+			Metadata: &runtimeapi.PodSandboxMetadata{
+				Name:      "nginx-7c79c4bf97-hx2xk",
+				Uid:       "5831c41b-55ba-4e82-9c6e-2d3ad9d8bfe9",
+				Namespace: "default",
+			},
+			State: runtimeapi.PodSandboxState_SANDBOX_READY,
+			Network: &runtimeapi.PodSandboxNetworkStatus{
+				Ip:            "10.244.0.12",
+				AdditionalIps: []*runtimeapi.PodIP{{Ip: "fd00:10:244::c"}},
+			},
+			Linux: &runtimeapi.LinuxPodSandboxStatus{
+				Namespaces: &runtimeapi.Namespace{
+					Options: &runtimeapi.NamespaceOption{
+						Network: runtimeapi.NamespaceMode_POD,
+						Pid:     runtimeapi.NamespaceMode_CONTAINER,
+						Ipc:     runtimeapi.NamespaceMode_POD,
+					},
+				},
+			},
+			Labels: map[string]string{
+				"app": "nginx",
+			},
+			Annotations: map[string]string{
+				"kubernetes.io/config.source": "api",
+			},
+			RuntimeHandler: "kata",
+		},
+	}, nil
+}
+
+func startFakeServer(t *testing.T) string {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "cri.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	runtimeapi.RegisterRuntimeServiceServer(server, &fakeRuntimeServer{})
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	return socketPath
+}
+
+func TestGetMetadata(t *testing.T) {
+	t.Parallel()
+
+	collector := cri.New(
+		cri.WithSocketPath("unix://"+startFakeServer(t)),
+		cri.WithContainerIDGetter(&containerIDGetter{id: containerID}),
+	)
+
+	expectedLabels := map[string][]string{
+		"cri:container:annotation:io.kubernetes.container.restartCount": {"2"},
+		"cri:container:attempt":                            {"2"},
+		"cri:container:id":                                 {containerID},
+		"cri:container:label:io.kubernetes.container.name": {"nginx"},
+		"cri:container:name":                               {"nginx"},
+		"cri:container:state":                              {"running"},
+		"cri:image:name":                                   {"docker.io/library/nginx:1.25.3"},
+		"cri:image:ref":                                    {imageRef},
+		"cri:image:user-specified":                         {"nginx:1.25.3"},
+		"cri:mount": {
+			"/var/lib/kubelet/pods/5831c41b/volumes/kubernetes.io~projected/kube-api-access:/var/run/secrets/kubernetes.io/serviceaccount:ro",
+			"/mnt/data:/data:rw",
+		},
+		"cri:pod:annotation:kubernetes.io/config.source": {"api"},
+		"cri:pod:attempt":                {"0"},
+		"cri:pod:id":                     {podSandboxID},
+		"cri:pod:ip":                     {"10.244.0.12", "fd00:10:244::c"},
+		"cri:pod:label:app":              {"nginx"},
+		"cri:pod:name":                   {"nginx-7c79c4bf97-hx2xk"},
+		"cri:pod:namespace":              {"default"},
+		"cri:pod:namespace-mode:ipc":     {"pod"},
+		"cri:pod:namespace-mode:network": {"pod"},
+		"cri:pod:namespace-mode:pid":     {"container"},
+		"cri:pod:state":                  {"ready"},
+		"cri:pod:uid":                    {"5831c41b-55ba-4e82-9c6e-2d3ad9d8bfe9"},
+		"cri:runtime-handler":            {"kata"},
+	}
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedLabels, map[string][]string(md.GetLabels()))
+}
+
+func TestGetMetadataNotFound(t *testing.T) {
+	t.Parallel()
+
+	socketPath := startFakeServer(t)
+
+	collector := cri.New(
+		cri.WithSocketPath(socketPath),
+		cri.WithContainerIDGetter(&containerIDGetter{id: "missing"}),
+	)
+
+	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.ErrorIs(t, err, cri.ContainerNotFoundError)
+
+	collector = cri.New(
+		cri.WithSocketPath(socketPath),
+		cri.WithContainerIDGetter(&containerIDGetter{id: "missing"}),
+		cri.WithSkipOnSoftError(),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	require.NoError(t, err)
+	assert.Empty(t, md.GetLabels())
+}
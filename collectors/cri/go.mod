@@ -0,0 +1,30 @@
+module github.com/gezacorp/metadatax/collectors/cri
+
+go 1.24.4
+
+require (
+	emperror.dev/errors v0.8.1
+	github.com/gezacorp/metadatax v0.0.0-20250619152456-c2ae8300820c
+	github.com/prometheus/procfs v0.15.1
+	github.com/stretchr/testify v1.10.0
+	google.golang.org/grpc v1.73.0
+	k8s.io/cri-api v0.33.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gogo/protobuf v1.3.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/multierr v1.11.0 // indirect
+	golang.org/x/net v0.38.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/gezacorp/metadatax => ../../
@@ -0,0 +1,51 @@
+package cri
+
+import (
+	"os"
+
+	"emperror.dev/errors"
+	"github.com/prometheus/procfs"
+
+	cgroupparser "github.com/gezacorp/metadatax/cgroups"
+)
+
+type Cgroup = procfs.Cgroup
+
+func GetProc(pid int) (procfs.Proc, error) {
+	hostProc := os.Getenv("HOST_PROC")
+	if hostProc == "" {
+		return procfs.NewProc(pid)
+	}
+
+	fs, fsErr := procfs.NewFS(hostProc)
+	if fsErr != nil {
+		return procfs.Proc{}, errors.WrapIf(fsErr, "could not create a new procfs")
+	}
+
+	return fs.Proc(pid)
+}
+
+func GetCgroupsForPID(pid int) ([]Cgroup, error) {
+	proc, err := GetProc(pid)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not get process info")
+	}
+
+	cgroups, err := proc.Cgroups()
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not get cgroups")
+	}
+
+	return cgroups, nil
+}
+
+func GetContainerIDFromCgroups(cgroups []Cgroup) string {
+	paths := make([]string, 0, len(cgroups))
+	for _, cgroup := range cgroups {
+		paths = append(paths, cgroup.Path)
+	}
+
+	info, _ := cgroupparser.Parse(paths)
+
+	return info.ContainerID
+}
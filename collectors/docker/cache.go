@@ -0,0 +1,216 @@
+package docker
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+const (
+	defaultCacheSyncTimeout   = time.Second
+	defaultCacheRetryInterval = 5 * time.Second
+)
+
+type ContainerEventSource interface {
+	Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error)
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+}
+
+// containerCache is a ContainerInspector serving inspect results of running
+// containers from an index kept up to date by the docker events stream.
+// Lookups missing the index fall back to the wrapped inspector.
+type containerCache struct {
+	inspector     ContainerInspector
+	source        ContainerEventSource
+	syncTimeout   time.Duration
+	retryInterval time.Duration
+
+	containers map[string]container.InspectResponse
+	pids       map[int]string
+	synced     chan struct{}
+	syncOnce   sync.Once
+
+	mu sync.RWMutex
+}
+
+func newContainerCache(inspector ContainerInspector, source ContainerEventSource) *containerCache {
+	return &containerCache{
+		inspector:     inspector,
+		source:        source,
+		syncTimeout:   defaultCacheSyncTimeout,
+		retryInterval: defaultCacheRetryInterval,
+		containers:    map[string]container.InspectResponse{},
+		pids:          map[int]string{},
+		synced:        make(chan struct{}),
+	}
+}
+
+// run watches the events stream until ctx is done, resyncing the index
+// whenever the stream has to be reopened.
+func (c *containerCache) run(ctx context.Context) {
+	for {
+		_ = c.watch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.retryInterval):
+		}
+	}
+}
+
+func (c *containerCache) watch(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// subscribe before listing, so no event is lost between the two
+	messages, errs := c.source.Events(ctx, events.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("type", string(events.ContainerEventType))),
+	})
+
+	if err := c.resync(ctx); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errs:
+			return err
+		case msg := <-messages:
+			c.handle(ctx, msg)
+		}
+	}
+}
+
+func (c *containerCache) resync(ctx context.Context) error {
+	summaries, err := c.source.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	containers := make(map[string]container.InspectResponse, len(summaries))
+	for _, summary := range summaries {
+		if containerJSON, err := c.inspector.ContainerInspect(ctx, summary.ID); err == nil {
+			containers[summary.ID] = containerJSON
+		}
+	}
+
+	c.mu.Lock()
+	c.containers = map[string]container.InspectResponse{}
+	c.pids = map[int]string{}
+	for id, containerJSON := range containers {
+		c.store(id, containerJSON)
+	}
+	c.mu.Unlock()
+
+	c.syncOnce.Do(func() {
+		close(c.synced)
+	})
+
+	return nil
+}
+
+func (c *containerCache) handle(ctx context.Context, msg events.Message) {
+	if msg.Type != events.ContainerEventType {
+		return
+	}
+
+	action := msg.Action
+	// health status events carry the status in the action, like "health_status: healthy"
+	if strings.HasPrefix(string(action), string(events.ActionHealthStatus)) {
+		action = events.ActionHealthStatus
+	}
+
+	switch action {
+	case events.ActionCreate, events.ActionStart, events.ActionRename, events.ActionUpdate, events.ActionHealthStatus:
+		containerJSON, err := c.inspector.ContainerInspect(ctx, msg.Actor.ID)
+		if err != nil {
+			c.remove(msg.Actor.ID)
+
+			return
+		}
+
+		c.mu.Lock()
+		c.store(msg.Actor.ID, containerJSON)
+		c.mu.Unlock()
+	case events.ActionDie, events.ActionDestroy:
+		c.remove(msg.Actor.ID)
+	}
+}
+
+// store must be called with the lock held.
+func (c *containerCache) store(id string, containerJSON container.InspectResponse) {
+	c.removeLocked(id)
+
+	c.containers[id] = containerJSON
+	if containerJSON.ContainerJSONBase != nil && containerJSON.State != nil && containerJSON.State.Pid > 0 {
+		c.pids[containerJSON.State.Pid] = id
+	}
+}
+
+func (c *containerCache) remove(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeLocked(id)
+}
+
+func (c *containerCache) removeLocked(id string) {
+	if containerJSON, ok := c.containers[id]; ok && containerJSON.ContainerJSONBase != nil && containerJSON.State != nil {
+		if c.pids[containerJSON.State.Pid] == id {
+			delete(c.pids, containerJSON.State.Pid)
+		}
+	}
+
+	delete(c.containers, id)
+}
+
+// waitForSync waits a bounded time for the initial listing, so lookups right
+// after startup do not all fall back to inspect.
+func (c *containerCache) waitForSync(ctx context.Context) {
+	select {
+	case <-c.synced:
+	case <-ctx.Done():
+	case <-time.After(c.syncTimeout):
+	}
+}
+
+func (c *containerCache) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	c.waitForSync(ctx)
+
+	c.mu.RLock()
+	containerJSON, ok := c.containers[containerID]
+	c.mu.RUnlock()
+
+	if ok {
+		return containerJSON, nil
+	}
+
+	containerJSON, err := c.inspector.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return containerJSON, err
+	}
+
+	if containerJSON.ContainerJSONBase != nil && containerJSON.State != nil && containerJSON.State.Running {
+		c.mu.Lock()
+		c.store(containerID, containerJSON)
+		c.mu.Unlock()
+	}
+
+	return containerJSON, nil
+}
+
+// GetContainerIDFromPID returns the container whose init process is pid.
+func (c *containerCache) GetContainerIDFromPID(pid int) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.pids[pid], nil
+}
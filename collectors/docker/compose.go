@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/gezacorp/metadatax"
+)
+
+const (
+	composeLabelPrefix = "com.docker.compose."
+	swarmLabelPrefix   = "com.docker.swarm."
+
+	stackNamespaceLabel = "com.docker.stack.namespace"
+)
+
+var composeLabels = map[string]string{
+	"project":                  "project",
+	"project.working_dir":      "working-dir",
+	"project.config_files":     "config-files",
+	"project.environment_file": "environment-file",
+	"service":                  "service",
+	"container-number":         "container-number",
+	"config-hash":              "config-hash",
+	"oneoff":                   "oneoff",
+	"version":                  "version",
+}
+
+var swarmLabels = map[string]string{
+	"service.id":   "service:id",
+	"service.name": "service:name",
+	"task.id":      "task:id",
+	"task.name":    "task:name",
+	"node.id":      "node:id",
+}
+
+func (c *collector) compose(containerJSON container.InspectResponse, md metadatax.MetadataContainer) {
+	cmd := md.Segment("compose")
+	for label, key := range composeLabels {
+		cmd.AddLabel(key, containerJSON.Config.Labels[composeLabelPrefix+label])
+	}
+}
+
+func (c *collector) swarm(containerJSON container.InspectResponse, md metadatax.MetadataContainer) {
+	smd := md.Segment("swarm")
+	for label, key := range swarmLabels {
+		smd.AddLabel(key, containerJSON.Config.Labels[swarmLabelPrefix+label])
+	}
+	smd.AddLabel("stack:namespace", containerJSON.Config.Labels[stackNamespaceLabel])
+}
@@ -4,12 +4,18 @@ import (
 	"context"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"emperror.dev/errors"
 	cerrdefs "github.com/containerd/errdefs"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/connhelper"
 	docker_ctx "github.com/docker/cli/cli/context/docker"
 	"github.com/docker/cli/cli/context/store"
 	"github.com/docker/cli/cli/flags"
@@ -28,14 +34,27 @@ const (
 var ContainerIDNotFoundError = errors.Sentinel("could not find container id for pid")
 
 type collector struct {
-	socketPath         string
+	socketPaths        []string
 	dockerClientOpts   []client.Opt
 	containerInspector ContainerInspector
+	inspectorFactory   func(host string) (ContainerInspector, error)
 	containerIDGetter  ContainerIDGetter
+	userRuntimeDir     string
+	imageInspection    bool
+	imageInspector     ImageInspector
+	imageCacheTTL      time.Duration
+	swarmInspection    bool
+	swarmInspector     SwarmInspector
+	cacheCtx           context.Context
+	eventSource        ContainerEventSource
 
 	mdContainerInitFunc func() metadatax.MetadataContainer
 	skipOnSoftError     bool
 	hasDocker           *bool
+
+	endpoints map[string]*endpoint
+	owners    map[string]*endpoint
+	mu        sync.Mutex
 }
 
 type ContainerInspector interface {
@@ -54,9 +73,11 @@ func WithDockerClientOpts(opts ...client.Opt) CollectorOption {
 	}
 }
 
+// WithSocketPath adds a daemon endpoint. It can be given multiple times, each
+// container is then looked up on the daemon owning it.
 func WithSocketPath(socketPath string) CollectorOption {
 	return func(c *collector) {
-		c.socketPath = socketPath
+		c.socketPaths = append(c.socketPaths, socketPath)
 	}
 }
 
@@ -66,12 +87,82 @@ func WithContainerInspector(inspector ContainerInspector) CollectorOption {
 	}
 }
 
+// WithContainerInspectorFactory sets how inspectors are created for the
+// discovered daemon endpoints. The docker client is used by default.
+func WithContainerInspectorFactory(fn func(host string) (ContainerInspector, error)) CollectorOption {
+	return func(c *collector) {
+		c.inspectorFactory = fn
+	}
+}
+
+// WithUserRuntimeDir sets the directory holding the runtime directories of
+// the users, where rootless daemons put their sockets. Defaults to /run/user.
+func WithUserRuntimeDir(dir string) CollectorOption {
+	return func(c *collector) {
+		c.userRuntimeDir = dir
+	}
+}
+
 func WithContainerIDGetter(containerIDGetter ContainerIDGetter) CollectorOption {
 	return func(c *collector) {
 		c.containerIDGetter = containerIDGetter
 	}
 }
 
+// WithImageInspection adds the details of the container image. Images are
+// inspected using the docker client unless an ImageInspector is given.
+func WithImageInspection() CollectorOption {
+	return func(c *collector) {
+		c.imageInspection = true
+	}
+}
+
+func WithImageInspector(inspector ImageInspector) CollectorOption {
+	return func(c *collector) {
+		c.imageInspection = true
+		c.imageInspector = inspector
+	}
+}
+
+// WithImageCacheTTL sets how long the details of an image are reused before
+// it is inspected again.
+func WithImageCacheTTL(ttl time.Duration) CollectorOption {
+	return func(c *collector) {
+		c.imageCacheTTL = ttl
+	}
+}
+
+// WithSwarmInspection adds the mode and replicas of the swarm service and the
+// slot of the task a container belongs to. Services and tasks are inspected
+// using the docker client unless a SwarmInspector is given.
+func WithSwarmInspection() CollectorOption {
+	return func(c *collector) {
+		c.swarmInspection = true
+	}
+}
+
+func WithSwarmInspector(inspector SwarmInspector) CollectorOption {
+	return func(c *collector) {
+		c.swarmInspection = true
+		c.swarmInspector = inspector
+	}
+}
+
+// WithEventDrivenCache keeps an index of the running containers up to date
+// from the docker events stream until ctx is done. Events are read using the
+// docker client unless a ContainerEventSource is given.
+func WithEventDrivenCache(ctx context.Context) CollectorOption {
+	return func(c *collector) {
+		c.cacheCtx = ctx
+	}
+}
+
+func WithContainerEventSource(source ContainerEventSource) CollectorOption {
+	return func(c *collector) {
+		c.eventSource = source
+	}
+}
+
 func CollectorWithMetadataContainerInitFunc(fn func() metadatax.MetadataContainer) CollectorOption {
 	return func(c *collector) {
 		c.mdContainerInitFunc = fn
@@ -85,20 +176,31 @@ func WithSkipOnSoftError() CollectorOption {
 }
 
 func New(opts ...CollectorOption) metadatax.Collector {
-	c := &collector{}
+	c := &collector{
+		endpoints: map[string]*endpoint{},
+		owners:    map[string]*endpoint{},
+	}
 
 	for _, f := range opts {
 		f(c)
 	}
 
-	if c.socketPath == "" {
-		if host, err := GetCurrentContextHost(); err == nil {
-			c.socketPath = host
+	if len(c.socketPaths) == 0 {
+		c.socketPaths = discoverSocketPaths()
+	}
+
+	if c.inspectorFactory == nil {
+		c.inspectorFactory = func(host string) (ContainerInspector, error) {
+			return c.getDockerClient(host)
 		}
 	}
 
-	if c.socketPath == "" {
-		c.socketPath = defaultSocketPath
+	if c.userRuntimeDir == "" {
+		c.userRuntimeDir = userRuntimeDir()
+	}
+
+	if c.imageCacheTTL == 0 {
+		c.imageCacheTTL = defaultImageCacheTTL
 	}
 
 	if c.containerIDGetter == nil {
@@ -119,7 +221,7 @@ func (c *collector) HasDocker() bool {
 		return *c.hasDocker
 	}
 
-	ret := c.isSocketPathExists(c.socketPath)
+	ret := slices.ContainsFunc(c.socketPaths, c.isHostAvailable)
 	c.hasDocker = &ret
 
 	return ret
@@ -143,21 +245,18 @@ func (c *collector) isSocketPathExists(path string) bool {
 func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContainer, error) {
 	md := c.mdContainerInitFunc()
 
-	if !c.HasDocker() {
-		return md, nil
+	pid, found := metadatax.PIDFromContext(ctx)
+	if !found {
+		return nil, metadatax.PIDNotFoundError
 	}
 
-	if c.containerInspector == nil {
-		var err error
-
-		if c.containerInspector, err = c.getDockerClient(); err != nil {
-			return nil, errors.WrapIf(err, "could not get docker client")
-		}
+	candidates, err := c.candidateEndpoints(int(pid))
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not get docker client")
 	}
 
-	pid, found := metadatax.PIDFromContext(ctx)
-	if !found {
-		return nil, metadatax.PIDNotFoundError
+	if len(candidates) == 0 {
+		return md, nil
 	}
 
 	containerID, err := c.containerIDGetter.GetContainerIDFromPID(int(pid))
@@ -169,6 +268,10 @@ func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContaine
 		return nil, errors.WrapIfWithDetails(err, "could not get cgroups from pid", "pid", pid)
 	}
 
+	if containerID == "" {
+		containerID = containerIDFromCaches(candidates, int(pid))
+	}
+
 	if containerID == "" {
 		if c.skipOnSoftError {
 			return md, nil
@@ -177,7 +280,7 @@ func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContaine
 		return nil, errors.WithDetails(ContainerIDNotFoundError, "pid", pid)
 	}
 
-	containerJSON, err := c.containerInspector.ContainerInspect(ctx, containerID)
+	containerJSON, e, err := c.inspect(ctx, candidates, containerID)
 	if c.skipOnSoftError && cerrdefs.IsNotFound(err) {
 		return md, nil
 	}
@@ -192,12 +295,32 @@ func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContaine
 		c.envs,
 		c.image,
 		c.network,
+		c.mounts,
+		c.resources,
+		c.security,
+		c.restart,
+		c.health,
+		c.timestamps,
+		c.compose,
+		c.swarm,
 	}
 
 	for _, f := range getters {
 		f(containerJSON, md)
 	}
 
+	if e.imageCache != nil {
+		if err := imageDetails(ctx, e.imageCache, containerJSON.Image, md); err != nil && !c.skipOnSoftError {
+			return nil, err
+		}
+	}
+
+	if e.swarmCache != nil {
+		if err := swarmDetails(ctx, e.swarmCache, containerJSON, md); err != nil && !c.skipOnSoftError {
+			return nil, err
+		}
+	}
+
 	return md, nil
 }
 
@@ -249,10 +372,149 @@ func (c *collector) network(containerJSON container.InspectResponse, md metadata
 	}
 }
 
-func (c *collector) getDockerClient() (*client.Client, error) {
+func (c *collector) mounts(containerJSON container.InspectResponse, md metadatax.MetadataContainer) {
+	for _, mount := range containerJSON.Mounts {
+		mmd := md.Segment("mount").Segment(mount.Destination)
+		mmd.AddLabel("source", mount.Source)
+		mmd.AddLabel("type", string(mount.Type))
+		mmd.AddLabel("rw", strconv.FormatBool(mount.RW))
+		mmd.AddLabel("volume-name", mount.Name)
+	}
+}
+
+func (c *collector) resources(containerJSON container.InspectResponse, md metadatax.MetadataContainer) {
+	resources := containerJSON.HostConfig.Resources
+	rmd := md.Segment("resources")
+
+	// zero means unlimited or the daemon default
+	for k, v := range map[string]int64{
+		"memory":             resources.Memory,
+		"memory-reservation": resources.MemoryReservation,
+		"memory-swap":        resources.MemorySwap,
+		"nano-cpus":          resources.NanoCPUs,
+		"cpu-shares":         resources.CPUShares,
+		"cpu-quota":          resources.CPUQuota,
+		"cpu-period":         resources.CPUPeriod,
+	} {
+		if v != 0 {
+			rmd.AddLabel(k, strconv.FormatInt(v, 10))
+		}
+	}
+
+	rmd.AddLabel("cpuset-cpus", resources.CpusetCpus)
+	if resources.PidsLimit != nil && *resources.PidsLimit > 0 {
+		rmd.AddLabel("pids-limit", strconv.FormatInt(*resources.PidsLimit, 10))
+	}
+}
+
+func (c *collector) security(containerJSON container.InspectResponse, md metadatax.MetadataContainer) {
+	smd := md.Segment("security")
+	smd.AddLabel("privileged", strconv.FormatBool(containerJSON.HostConfig.Privileged))
+	smd.AddLabel("readonly-rootfs", strconv.FormatBool(containerJSON.HostConfig.ReadonlyRootfs))
+	smd.AddLabel("user", containerJSON.Config.User)
+	smd.AddLabel("apparmor-profile", containerJSON.AppArmorProfile)
+
+	for _, capability := range containerJSON.HostConfig.CapAdd {
+		smd.AddLabel("cap-add", capability)
+	}
+	for _, capability := range containerJSON.HostConfig.CapDrop {
+		smd.AddLabel("cap-drop", capability)
+	}
+
+	// options are given as key=value or, in older API versions, key:value
+	for _, opt := range containerJSON.HostConfig.SecurityOpt {
+		k, v, found := strings.Cut(opt, "=")
+		if !found {
+			k, v, _ = strings.Cut(opt, ":")
+		}
+
+		switch k {
+		case "seccomp":
+			smd.AddLabel("seccomp-profile", v)
+		case "no-new-privileges":
+			if v == "" {
+				v = "true"
+			}
+			smd.AddLabel("no-new-privileges", v)
+		}
+	}
+}
+
+func (c *collector) restart(containerJSON container.InspectResponse, md metadatax.MetadataContainer) {
+	rmd := md.Segment("restart")
+	rmd.AddLabel("policy", string(containerJSON.HostConfig.RestartPolicy.Name))
+	if containerJSON.HostConfig.RestartPolicy.MaximumRetryCount > 0 {
+		rmd.AddLabel("max-retries", strconv.Itoa(containerJSON.HostConfig.RestartPolicy.MaximumRetryCount))
+	}
+	rmd.AddLabel("count", strconv.Itoa(containerJSON.RestartCount))
+}
+
+func (c *collector) health(containerJSON container.InspectResponse, md metadatax.MetadataContainer) {
+	if containerJSON.State == nil || containerJSON.State.Health == nil {
+		return
+	}
+
+	md.Segment("health").
+		AddLabel("status", containerJSON.State.Health.Status).
+		AddLabel("failing-streak", strconv.Itoa(containerJSON.State.Health.FailingStreak))
+}
+
+func (c *collector) timestamps(containerJSON container.InspectResponse, md metadatax.MetadataContainer) {
+	md.AddLabel("created-at", formatTimestamp(containerJSON.Created))
+	if containerJSON.State != nil {
+		md.AddLabel("started-at", formatTimestamp(containerJSON.State.StartedAt))
+	}
+}
+
+// formatTimestamp normalizes API timestamps to UTC and drops unset ones.
+func formatTimestamp(value string) string {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// discoverSocketPaths returns the daemon hosts in the order of precedence of
+// the docker CLI, followed by the rootless and rootful local defaults.
+func discoverSocketPaths() []string {
+	hosts := []string{os.Getenv(client.EnvOverrideHost)}
+
+	if host, err := GetCurrentContextHost(); err == nil {
+		hosts = append(hosts, host)
+	}
+
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		hosts = append(hosts, "unix://"+filepath.Join(dir, "docker.sock"))
+	}
+
+	hosts = append(hosts, defaultSocketPath)
+
+	var socketPaths []string
+	for _, host := range hosts {
+		if host != "" && !slices.Contains(socketPaths, host) {
+			socketPaths = append(socketPaths, host)
+		}
+	}
+
+	return socketPaths
+}
+
+func (c *collector) getDockerClient(host string) (*client.Client, error) {
 	var opts []client.Opt
-	if c.socketPath != "" {
-		opts = append(opts, client.WithHost(c.socketPath))
+	if host != "" {
+		// ssh hosts are reached through the ssh binary, like the CLI does
+		helper, err := connhelper.GetConnectionHelper(host)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not get connection helper", "host", host)
+		}
+
+		if helper != nil {
+			opts = append(opts, client.WithHost(helper.Host), client.WithDialContext(helper.Dialer))
+		} else {
+			opts = append(opts, client.WithHost(host))
+		}
 	}
 	opts = append(opts, client.WithAPIVersionNegotiation())
 
@@ -3,12 +3,24 @@ package docker_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	cerrdefs "github.com/containerd/errdefs"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/gezacorp/metadatax"
 	"github.com/gezacorp/metadatax/collectors/docker"
@@ -20,10 +32,29 @@ func (g *containerIDGetter) GetContainerIDFromPID(pid int) (string, error) {
 	return "test", nil
 }
 
-type containerInspector struct{}
+type pidContainerIDGetter struct {
+	pid int
+}
+
+func (g *pidContainerIDGetter) GetContainerIDFromPID(pid int) (string, error) {
+	if pid == g.pid {
+		return "", nil
+	}
+
+	return "test", nil
+}
+
+type containerInspector struct {
+	file string
+}
+
+func (i *containerInspector) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	name := i.file
+	if name == "" {
+		name = "container.json"
+	}
 
-func (*containerInspector) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
-	file, err := os.Open("testdata/container.json")
+	file, err := os.Open("testdata/" + name)
 	if err != nil {
 		return container.InspectResponse{}, err
 	}
@@ -40,6 +71,142 @@ func (*containerInspector) ContainerInspect(ctx context.Context, containerID str
 	return containerJSON, nil
 }
 
+type notFoundContainerInspector struct {
+	calls atomic.Int32
+}
+
+func (i *notFoundContainerInspector) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	i.calls.Add(1)
+
+	return container.InspectResponse{}, cerrdefs.ErrNotFound
+}
+
+func listenUnix(t *testing.T, path string) string {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	return "unix://" + path
+}
+
+func inspectorFactory(inspectors map[string]docker.ContainerInspector) func(string) (docker.ContainerInspector, error) {
+	return func(host string) (docker.ContainerInspector, error) {
+		if inspector, ok := inspectors[host]; ok {
+			return inspector, nil
+		}
+
+		return &notFoundContainerInspector{}, nil
+	}
+}
+
+type imageInspector struct {
+	calls atomic.Int32
+}
+
+func (i *imageInspector) ImageInspect(ctx context.Context, imageID string, opts ...client.ImageInspectOption) (image.InspectResponse, error) {
+	i.calls.Add(1)
+
+	content, err := os.ReadFile("testdata/image.json")
+	if err != nil {
+		return image.InspectResponse{}, err
+	}
+
+	var imageJSON image.InspectResponse
+	if err := json.Unmarshal(content, &imageJSON); err != nil {
+		return image.InspectResponse{}, err
+	}
+
+	return imageJSON, nil
+}
+
+type swarmInspector struct {
+	serviceCalls atomic.Int32
+	taskCalls    atomic.Int32
+}
+
+func (i *swarmInspector) ServiceInspectWithRaw(ctx context.Context, serviceID string, opts swarm.ServiceInspectOptions) (swarm.Service, []byte, error) {
+	i.serviceCalls.Add(1)
+
+	replicas := uint64(3)
+
+	return swarm.Service{
+		ID: serviceID,
+		Spec: swarm.ServiceSpec{
+			Annotations: swarm.Annotations{
+				Name:   "web_nginx",
+				Labels: map[string]string{"com.docker.stack.namespace": "web"},
+			},
+			Mode: swarm.ServiceMode{
+				Replicated: &swarm.ReplicatedService{Replicas: &replicas},
+			},
+		},
+	}, nil, nil
+}
+
+func (i *swarmInspector) TaskInspectWithRaw(ctx context.Context, taskID string) (swarm.Task, []byte, error) {
+	i.taskCalls.Add(1)
+
+	return swarm.Task{ID: taskID, Slot: 2}, nil, nil
+}
+
+// workerSwarmInspector fails like the daemon of a swarm worker node.
+type workerSwarmInspector struct {
+	calls atomic.Int32
+}
+
+var errNotSwarmManager = errors.New("Error response from daemon: This node is not a swarm manager. Worker nodes can't be used to view or modify cluster state.")
+
+func (i *workerSwarmInspector) ServiceInspectWithRaw(ctx context.Context, serviceID string, opts swarm.ServiceInspectOptions) (swarm.Service, []byte, error) {
+	i.calls.Add(1)
+
+	return swarm.Service{}, nil, errNotSwarmManager
+}
+
+func (i *workerSwarmInspector) TaskInspectWithRaw(ctx context.Context, taskID string) (swarm.Task, []byte, error) {
+	i.calls.Add(1)
+
+	return swarm.Task{}, nil, errNotSwarmManager
+}
+
+type failingSwarmInspector struct{}
+
+func (*failingSwarmInspector) ServiceInspectWithRaw(ctx context.Context, serviceID string, opts swarm.ServiceInspectOptions) (swarm.Service, []byte, error) {
+	return swarm.Service{}, nil, errors.New("connection reset")
+}
+
+func (*failingSwarmInspector) TaskInspectWithRaw(ctx context.Context, taskID string) (swarm.Task, []byte, error) {
+	return swarm.Task{}, nil, errors.New("connection reset")
+}
+
+type countingContainerInspector struct {
+	containerInspector
+	calls atomic.Int32
+}
+
+func (i *countingContainerInspector) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	i.calls.Add(1)
+
+	return i.containerInspector.ContainerInspect(ctx, containerID)
+}
+
+type eventSource struct {
+	messages chan events.Message
+}
+
+func (s *eventSource) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	return s.messages, make(chan error)
+}
+
+func (s *eventSource) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	return []container.Summary{{ID: "test"}}, nil
+}
+
 func TestGetMetadata(t *testing.T) {
 	t.Parallel()
 
@@ -49,19 +216,60 @@ func TestGetMetadata(t *testing.T) {
 	)
 
 	expectedLabels := map[string][]string{
-		"docker:cmdline":           {"/docker-entrypoint.sh nginx -g daemon off;"},
-		"docker:env:NGINX_VERSION": {"1.25.3"},
-		"docker:env:NJS_VERSION":   {"0.8.2"},
-		"docker:env:PATH":          {"/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"},
-		"docker:env:PKG_RELEASE":   {"1~bookworm"},
-		"docker:id":                {"3ac7ed50c6087bb468fd70d37a6e3ee8d5b554bcbde20bd83f9a9dfa14f0431e"},
-		"docker:image:hash":        {"sha256:c20060033e06f882b0fbe2db7d974d72e0887a3be5e554efdb0dcf8d53512647"},
-		"docker:image:name":        {"nginx"},
-		"docker:label:maintainer":  {"NGINX Docker Maintainers <docker-maint@nginx.com>"},
-		"docker:name":              {"awesome_sinoussi"},
-		"docker:network:hostname":  {"3ac7ed50c608"},
-		"docker:network:mode":      {"default"},
-		"docker:port-binding":      {"8080/tcp"},
+		"docker:cmdline":                                       {"/docker-entrypoint.sh nginx -g daemon off;"},
+		"docker:compose:config-files":                          {"/srv/web/compose.yaml"},
+		"docker:compose:config-hash":                           {"8d1f3a6c2b9e4f7a0c5d8e1b3f6a9c2d4e7b0a3f6c9d2e5b8a1f4c7d0e3b6a9c"},
+		"docker:compose:container-number":                      {"1"},
+		"docker:compose:oneoff":                                {"False"},
+		"docker:compose:project":                               {"web"},
+		"docker:compose:service":                               {"nginx"},
+		"docker:compose:version":                               {"2.29.1"},
+		"docker:compose:working-dir":                           {"/srv/web"},
+		"docker:created-at":                                    {"2023-11-23T08:42:53.752096586Z"},
+		"docker:env:NGINX_VERSION":                             {"1.25.3"},
+		"docker:env:NJS_VERSION":                               {"0.8.2"},
+		"docker:env:PATH":                                      {"/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"},
+		"docker:env:PKG_RELEASE":                               {"1~bookworm"},
+		"docker:id":                                            {"3ac7ed50c6087bb468fd70d37a6e3ee8d5b554bcbde20bd83f9a9dfa14f0431e"},
+		"docker:health:failing-streak":                         {"0"},
+		"docker:health:status":                                 {"healthy"},
+		"docker:image:hash":                                    {"sha256:c20060033e06f882b0fbe2db7d974d72e0887a3be5e554efdb0dcf8d53512647"},
+		"docker:image:name":                                    {"nginx"},
+		"docker:label:com.docker.compose.config-hash":          {"8d1f3a6c2b9e4f7a0c5d8e1b3f6a9c2d4e7b0a3f6c9d2e5b8a1f4c7d0e3b6a9c"},
+		"docker:label:com.docker.compose.container-number":     {"1"},
+		"docker:label:com.docker.compose.oneoff":               {"False"},
+		"docker:label:com.docker.compose.project":              {"web"},
+		"docker:label:com.docker.compose.project.config_files": {"/srv/web/compose.yaml"},
+		"docker:label:com.docker.compose.project.working_dir":  {"/srv/web"},
+		"docker:label:com.docker.compose.service":              {"nginx"},
+		"docker:label:com.docker.compose.version":              {"2.29.1"},
+		"docker:label:maintainer":                              {"NGINX Docker Maintainers <docker-maint@nginx.com>"},
+		"docker:mount:/usr/share/nginx/html:rw":                {"false"},
+		"docker:mount:/usr/share/nginx/html:source":            {"/srv/www"},
+		"docker:mount:/usr/share/nginx/html:type":              {"bind"},
+		"docker:mount:/var/cache/nginx:rw":                     {"true"},
+		"docker:mount:/var/cache/nginx:source":                 {"/var/lib/docker/volumes/nginx-cache/_data"},
+		"docker:mount:/var/cache/nginx:type":                   {"volume"},
+		"docker:mount:/var/cache/nginx:volume-name":            {"nginx-cache"},
+		"docker:name":                                          {"awesome_sinoussi"},
+		"docker:network:hostname":                              {"3ac7ed50c608"},
+		"docker:network:mode":                                  {"default"},
+		"docker:port-binding":                                  {"8080/tcp"},
+		"docker:resources:cpu-shares":                          {"512"},
+		"docker:resources:memory":                              {"268435456"},
+		"docker:resources:nano-cpus":                           {"500000000"},
+		"docker:resources:pids-limit":                          {"100"},
+		"docker:restart:count":                                 {"1"},
+		"docker:restart:policy":                                {"unless-stopped"},
+		"docker:security:apparmor-profile":                     {"docker-default"},
+		"docker:security:cap-add":                              {"NET_ADMIN"},
+		"docker:security:cap-drop":                             {"MKNOD"},
+		"docker:security:no-new-privileges":                    {"true"},
+		"docker:security:privileged":                           {"false"},
+		"docker:security:readonly-rootfs":                      {"true"},
+		"docker:security:seccomp-profile":                      {"unconfined"},
+		"docker:security:user":                                 {"101:101"},
+		"docker:started-at":                                    {"2023-11-23T08:42:54.166586671Z"},
 	}
 
 	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
@@ -69,3 +277,346 @@ func TestGetMetadata(t *testing.T) {
 
 	assert.Equal(t, expectedLabels, map[string][]string(md.GetLabels()))
 }
+
+func TestGetMetadataImageInspection(t *testing.T) {
+	t.Parallel()
+
+	inspector := &imageInspector{}
+	collector := docker.New(
+		docker.WithContainerInspector(&containerInspector{}),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+		docker.WithImageInspector(inspector),
+	)
+
+	expectedLabels := map[string][]string{
+		"docker:image:architecture": {"arm64"},
+		"docker:image:created":      {"2023-11-21T05:23:45.215458297Z"},
+		"docker:image:hash":         {"sha256:c20060033e06f882b0fbe2db7d974d72e0887a3be5e554efdb0dcf8d53512647"},
+		"docker:image:name":         {"nginx"},
+		"docker:image:os":           {"linux"},
+		"docker:image:repo-digest":  {"nginx@sha256:10d1f5b58f74683ad34eb29287e07dab1e90f10af243f151bb50aa5dbb4d62ee"},
+		"docker:image:revision":     {"7d4b3c1e0f2a9b8c6d5e4f3a2b1c0d9e8f7a6b5c"},
+		"docker:image:size":         {"192063326"},
+		"docker:image:source":       {"https://github.com/nginxinc/docker-nginx"},
+		"docker:image:tag":          {"nginx:latest", "nginx:1.25.3"},
+		"docker:image:variant":      {"v8"},
+		"docker:image:version":      {"1.25.3"},
+	}
+
+	for range 2 {
+		md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+		assert.Nil(t, err)
+
+		labels := map[string][]string{}
+		for k, v := range md.GetLabels() {
+			if strings.HasPrefix(k, "docker:image:") {
+				labels[k] = v
+			}
+		}
+		assert.Equal(t, expectedLabels, labels)
+	}
+
+	assert.Equal(t, int32(1), inspector.calls.Load())
+}
+
+type failingImageInspector struct{}
+
+func (i *failingImageInspector) ImageInspect(ctx context.Context, imageID string, opts ...client.ImageInspectOption) (image.InspectResponse, error) {
+	return image.InspectResponse{}, errors.New("connection reset by peer")
+}
+
+func TestGetMetadataImageInspectionSoftError(t *testing.T) {
+	t.Parallel()
+
+	collector := docker.New(
+		docker.WithContainerInspector(&containerInspector{}),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+		docker.WithImageInspector(&failingImageInspector{}),
+	)
+
+	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.ErrorContains(t, err, "could not inspect image")
+
+	collector = docker.New(
+		docker.WithContainerInspector(&containerInspector{}),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+		docker.WithImageInspector(&failingImageInspector{}),
+		docker.WithSkipOnSoftError(),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"awesome_sinoussi"}, md.GetLabels()["docker:name"])
+	assert.NotContains(t, md.GetLabels(), "docker:image:tag")
+}
+
+func TestGetMetadataImageCacheExpiry(t *testing.T) {
+	t.Parallel()
+
+	inspector := &imageInspector{}
+	collector := docker.New(
+		docker.WithContainerInspector(&containerInspector{}),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+		docker.WithImageInspector(inspector),
+		docker.WithImageCacheTTL(time.Nanosecond),
+	)
+
+	// tags of an image change, so its details are inspected again once expired
+	for range 2 {
+		md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"nginx:latest", "nginx:1.25.3"}, md.GetLabels()["docker:image:tag"])
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, int32(2), inspector.calls.Load())
+}
+
+func swarmLabels(md metadatax.MetadataContainer) map[string][]string {
+	labels := map[string][]string{}
+	for k, v := range md.GetLabels() {
+		if strings.HasPrefix(k, "docker:swarm:") {
+			labels[k] = v
+		}
+	}
+
+	return labels
+}
+
+func TestGetMetadataSwarm(t *testing.T) {
+	t.Parallel()
+
+	inspector := &swarmInspector{}
+	collector := docker.New(
+		docker.WithContainerInspector(&containerInspector{file: "swarm-container.json"}),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+		docker.WithSwarmInspector(inspector),
+	)
+
+	expectedLabels := map[string][]string{
+		"docker:swarm:node:id":          {"x4k2m9p7r5t3v1w8y6z0a2c4e"},
+		"docker:swarm:service:id":       {"k3m5o7q9s1u3w5y7a9c1e3g5i"},
+		"docker:swarm:service:mode":     {"replicated"},
+		"docker:swarm:service:name":     {"web_nginx"},
+		"docker:swarm:service:replicas": {"3"},
+		"docker:swarm:stack:namespace":  {"web"},
+		"docker:swarm:task:id":          {"q8n3v5x7z9b1d3f5h7j9l1n3p"},
+		"docker:swarm:task:name":        {"web_nginx.2.q8n3v5x7z9b1d3f5h7j9l1n3p"},
+		"docker:swarm:task:slot":        {"2"},
+	}
+
+	for range 2 {
+		md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+		assert.Nil(t, err)
+		assert.Equal(t, expectedLabels, swarmLabels(md))
+	}
+
+	// services and tasks are cached
+	assert.Equal(t, int32(1), inspector.serviceCalls.Load())
+	assert.Equal(t, int32(1), inspector.taskCalls.Load())
+}
+
+func TestGetMetadataSwarmWorker(t *testing.T) {
+	t.Parallel()
+
+	inspector := &workerSwarmInspector{}
+	collector := docker.New(
+		docker.WithContainerInspector(&containerInspector{file: "swarm-container.json"}),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+		docker.WithSwarmInspector(inspector),
+	)
+
+	// only the labels of the container are known on workers
+	expectedLabels := map[string][]string{
+		"docker:swarm:node:id":         {"x4k2m9p7r5t3v1w8y6z0a2c4e"},
+		"docker:swarm:service:id":      {"k3m5o7q9s1u3w5y7a9c1e3g5i"},
+		"docker:swarm:service:name":    {"web_nginx"},
+		"docker:swarm:stack:namespace": {"web"},
+		"docker:swarm:task:id":         {"q8n3v5x7z9b1d3f5h7j9l1n3p"},
+		"docker:swarm:task:name":       {"web_nginx.2.q8n3v5x7z9b1d3f5h7j9l1n3p"},
+	}
+
+	for range 2 {
+		md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+		assert.Nil(t, err)
+		assert.Equal(t, expectedLabels, swarmLabels(md))
+	}
+
+	// the worker is not asked again
+	assert.Equal(t, int32(1), inspector.calls.Load())
+}
+
+func TestGetMetadataSwarmSoftError(t *testing.T) {
+	t.Parallel()
+
+	collector := docker.New(
+		docker.WithContainerInspector(&containerInspector{file: "swarm-container.json"}),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+		docker.WithSwarmInspector(&failingSwarmInspector{}),
+	)
+
+	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.ErrorContains(t, err, "could not inspect task")
+
+	collector = docker.New(
+		docker.WithContainerInspector(&containerInspector{file: "swarm-container.json"}),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+		docker.WithSwarmInspector(&failingSwarmInspector{}),
+		docker.WithSkipOnSoftError(),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"web_nginx"}, md.GetLabels()["docker:swarm:service:name"])
+}
+
+func TestGetMetadataEventDrivenCache(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	inspector := &countingContainerInspector{}
+	source := &eventSource{messages: make(chan events.Message)}
+	collector := docker.New(
+		docker.WithContainerInspector(inspector),
+		docker.WithContainerIDGetter(&pidContainerIDGetter{pid: 3153}),
+		docker.WithEventDrivenCache(ctx),
+		docker.WithContainerEventSource(source),
+	)
+
+	getName := func(pid int32) []string {
+		md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), pid))
+		assert.Nil(t, err)
+
+		return md.GetLabels()["docker:name"]
+	}
+
+	// served from the initial listing
+	assert.Equal(t, []string{"awesome_sinoussi"}, getName(1))
+	assert.Equal(t, []string{"awesome_sinoussi"}, getName(1))
+	assert.Equal(t, int32(1), inspector.calls.Load())
+
+	// resolved through the pid of the container init process
+	assert.Equal(t, []string{"awesome_sinoussi"}, getName(3153))
+	assert.Equal(t, int32(1), inspector.calls.Load())
+
+	// the channel is unbuffered, so the die event is handled once the next one is received
+	source.messages <- events.Message{Type: events.ContainerEventType, Action: events.ActionDie, Actor: events.Actor{ID: "test"}}
+	source.messages <- events.Message{Type: events.NetworkEventType, Action: events.ActionConnect}
+
+	assert.Equal(t, []string{"awesome_sinoussi"}, getName(1))
+	assert.Equal(t, int32(2), inspector.calls.Load())
+
+	source.messages <- events.Message{Type: events.ContainerEventType, Action: events.ActionRename, Actor: events.Actor{ID: "test"}}
+	source.messages <- events.Message{Type: events.NetworkEventType, Action: events.ActionConnect}
+
+	assert.Equal(t, []string{"awesome_sinoussi"}, getName(1))
+	assert.Equal(t, int32(3), inspector.calls.Load())
+	// health checks changing the status are re-inspected
+	source.messages <- events.Message{Type: events.ContainerEventType, Action: events.ActionHealthStatusUnhealthy, Actor: events.Actor{ID: "test"}}
+	source.messages <- events.Message{Type: events.NetworkEventType, Action: events.ActionConnect}
+
+	assert.Equal(t, []string{"awesome_sinoussi"}, getName(1))
+	assert.Equal(t, int32(4), inspector.calls.Load())
+
+	// exec events are not
+	source.messages <- events.Message{Type: events.ContainerEventType, Action: events.ActionExecStart, Actor: events.Actor{ID: "test"}}
+	source.messages <- events.Message{Type: events.NetworkEventType, Action: events.ActionConnect}
+
+	assert.Equal(t, []string{"awesome_sinoussi"}, getName(1))
+	assert.Equal(t, int32(4), inspector.calls.Load())
+}
+
+func TestGetMetadataMultipleEndpoints(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	rootful := listenUnix(t, filepath.Join(dir, "docker.sock"))
+	other := listenUnix(t, filepath.Join(dir, "other", "docker.sock"))
+
+	notFound := &notFoundContainerInspector{}
+	collector := docker.New(
+		docker.WithSocketPath(rootful),
+		docker.WithSocketPath(other),
+		docker.WithSocketPath("unix://"+filepath.Join(dir, "missing.sock")),
+		docker.WithUserRuntimeDir(filepath.Join(dir, "user")),
+		docker.WithContainerInspectorFactory(inspectorFactory(map[string]docker.ContainerInspector{
+			rootful: notFound,
+			other:   &containerInspector{},
+		})),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+	)
+
+	for range 2 {
+		md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), int32(os.Getpid())))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"awesome_sinoussi"}, md.GetLabels()["docker:name"])
+	}
+
+	// the owning daemon is remembered
+	assert.Equal(t, int32(1), notFound.calls.Load())
+}
+
+// TestGetMetadataRootlessEndpoint finds the rootless daemon by the user
+// service in the cgroup path of the process, as processes in the container
+// run with subordinate uids.
+func TestGetMetadataRootlessEndpoint(t *testing.T) {
+	t.Setenv("HOST_PROC", "testdata/proc")
+
+	dir := t.TempDir()
+	rootful := listenUnix(t, filepath.Join(dir, "docker.sock"))
+	rootless := listenUnix(t, filepath.Join(dir, "user", "1000", "docker.sock"))
+
+	notFound := &notFoundContainerInspector{}
+	collector := docker.New(
+		docker.WithSocketPath(rootful),
+		docker.WithUserRuntimeDir(filepath.Join(dir, "user")),
+		docker.WithContainerInspectorFactory(inspectorFactory(map[string]docker.ContainerInspector{
+			rootful:  notFound,
+			rootless: &containerInspector{},
+		})),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 4242))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"awesome_sinoussi"}, md.GetLabels()["docker:name"])
+
+	// the daemon of the container owner is asked first
+	assert.Equal(t, int32(0), notFound.calls.Load())
+}
+
+func TestGetMetadataRemoteEndpoint(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://10.0.0.1:2376")
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	t.Setenv("DOCKER_CONTEXT", "")
+
+	dir := t.TempDir()
+	collector := docker.New(
+		docker.WithUserRuntimeDir(dir),
+		docker.WithContainerInspectorFactory(inspectorFactory(map[string]docker.ContainerInspector{
+			"tcp://10.0.0.1:2376": &containerInspector{},
+		})),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), int32(os.Getpid())))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"awesome_sinoussi"}, md.GetLabels()["docker:name"])
+}
+
+func TestGetMetadataNoEndpoint(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	collector := docker.New(
+		docker.WithSocketPath("unix://"+filepath.Join(dir, "docker.sock")),
+		docker.WithUserRuntimeDir(dir),
+		docker.WithContainerIDGetter(&containerIDGetter{}),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), int32(os.Getpid())))
+	require.NoError(t, err)
+	assert.Empty(t, md.GetLabels())
+}
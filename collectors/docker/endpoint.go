@@ -0,0 +1,213 @@
+package docker
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+)
+
+const (
+	maxContainerOwners = 1024
+)
+
+// endpoint is a docker daemon along with the inspectors and caches bound to
+// it. Image and swarm lookups must go to the daemon owning the container.
+type endpoint struct {
+	host               string
+	containerInspector ContainerInspector
+	containerCache     *containerCache
+	imageCache         *imageCache
+	swarmCache         *swarmCache
+}
+
+func (c *collector) newEndpoint(host string, inspector ContainerInspector) *endpoint {
+	e := &endpoint{
+		host:               host,
+		containerInspector: inspector,
+	}
+
+	if c.cacheCtx != nil {
+		source := c.eventSource
+		if source == nil {
+			source, _ = inspector.(ContainerEventSource)
+		}
+
+		if source != nil {
+			e.containerCache = newContainerCache(inspector, source)
+			e.containerInspector = e.containerCache
+			go e.containerCache.run(c.cacheCtx)
+		}
+	}
+
+	if c.imageInspection {
+		imageInspector := c.imageInspector
+		if imageInspector == nil {
+			imageInspector, _ = inspector.(ImageInspector)
+		}
+
+		if imageInspector != nil {
+			e.imageCache = newImageCache(imageInspector, defaultImageCacheSize, c.imageCacheTTL)
+		}
+	}
+
+	if c.swarmInspection {
+		swarmInspector := c.swarmInspector
+		if swarmInspector == nil {
+			swarmInspector, _ = inspector.(SwarmInspector)
+		}
+
+		if swarmInspector != nil {
+			e.swarmCache = newSwarmCache(swarmInspector, defaultSwarmCacheSize)
+		}
+	}
+
+	return e
+}
+
+// getEndpoint returns the endpoint of host, connecting to it on first use.
+// Must be called with the lock held.
+func (c *collector) getEndpoint(host string) (*endpoint, error) {
+	if e, ok := c.endpoints[host]; ok {
+		return e, nil
+	}
+
+	inspector, err := c.inspectorFactory(host)
+	if err != nil {
+		return nil, err
+	}
+
+	e := c.newEndpoint(host, inspector)
+	c.endpoints[host] = e
+
+	return e, nil
+}
+
+// candidateEndpoints returns the endpoints which can own the container of
+// pid. The rootless daemon of the user owning the container comes first.
+func (c *collector) candidateEndpoints(pid int) ([]*endpoint, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.containerInspector != nil {
+		if c.endpoints[""] == nil {
+			c.endpoints[""] = c.newEndpoint("", c.containerInspector)
+		}
+
+		return []*endpoint{c.endpoints[""]}, nil
+	}
+
+	var candidates []*endpoint
+
+	if uid, err := GetRootlessUIDForPID(pid); err == nil {
+		socketPath := "unix://" + filepath.Join(c.userRuntimeDir, strconv.Itoa(uid), "docker.sock")
+		if !slices.Contains(c.socketPaths, socketPath) && c.isSocketPathExists(socketPath) {
+			e, err := c.getEndpoint(socketPath)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, e)
+		}
+	}
+
+	if !c.HasDocker() {
+		return candidates, nil
+	}
+
+	for _, socketPath := range c.socketPaths {
+		if !c.isHostAvailable(socketPath) {
+			continue
+		}
+
+		e, err := c.getEndpoint(socketPath)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, e)
+	}
+
+	return candidates, nil
+}
+
+// userRuntimeDir returns the directory of the per-user runtime dirs, on the
+// host when running in a container.
+func userRuntimeDir() string {
+	if dir := os.Getenv("HOST_RUN"); dir != "" {
+		return filepath.Join(dir, "user")
+	}
+
+	return "/run/user"
+}
+
+// isHostAvailable reports whether host is a remote daemon or an existing
+// local socket.
+func (c *collector) isHostAvailable(host string) bool {
+	if u, err := url.Parse(host); err == nil && u.Scheme != "" && u.Scheme != "unix" {
+		return true
+	}
+
+	return c.isSocketPathExists(host)
+}
+
+// inspect looks the container up on the endpoint known to own it, and on
+// all candidates in order otherwise.
+func (c *collector) inspect(ctx context.Context, candidates []*endpoint, containerID string) (container.InspectResponse, *endpoint, error) {
+	c.mu.Lock()
+	owner := c.owners[containerID]
+	c.mu.Unlock()
+
+	if owner != nil && slices.Contains(candidates, owner) {
+		containerJSON, err := owner.containerInspector.ContainerInspect(ctx, containerID)
+		if !cerrdefs.IsNotFound(err) {
+			return containerJSON, owner, err
+		}
+	}
+
+	var lastErr error
+	for _, e := range candidates {
+		containerJSON, err := e.containerInspector.ContainerInspect(ctx, containerID)
+		if err == nil {
+			c.setOwner(containerID, e)
+
+			return containerJSON, e, nil
+		}
+
+		// an unreachable daemon might own the container, so its error wins
+		// over the not found errors of the others
+		if lastErr == nil || !cerrdefs.IsNotFound(err) {
+			lastErr = err
+		}
+	}
+
+	return container.InspectResponse{}, nil, lastErr
+}
+
+func (c *collector) setOwner(containerID string, e *endpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.owners) >= maxContainerOwners {
+		clear(c.owners)
+	}
+	c.owners[containerID] = e
+}
+
+// containerIDFromCaches looks pid up in the event-driven caches.
+func containerIDFromCaches(candidates []*endpoint, pid int) string {
+	for _, e := range candidates {
+		if e.containerCache == nil {
+			continue
+		}
+
+		if containerID, _ := e.containerCache.GetContainerIDFromPID(pid); containerID != "" {
+			return containerID
+		}
+	}
+
+	return ""
+}
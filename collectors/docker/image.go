@@ -0,0 +1,112 @@
+package docker
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+
+	"github.com/gezacorp/metadatax"
+)
+
+const (
+	defaultImageCacheSize = 256
+	defaultImageCacheTTL  = time.Minute
+)
+
+type ImageInspector interface {
+	ImageInspect(ctx context.Context, imageID string, opts ...client.ImageInspectOption) (image.InspectResponse, error)
+}
+
+type imageCacheEntry struct {
+	image     image.InspectResponse
+	expiresAt time.Time
+}
+
+// imageCache keeps image inspect results keyed by image id for a while. The
+// id is content addressed, but the tags and repo digests of an image change
+// as it is tagged, pulled or pushed.
+type imageCache struct {
+	inspector  ImageInspector
+	entries    map[string]imageCacheEntry
+	maxEntries int
+	ttl        time.Duration
+
+	mu sync.Mutex
+}
+
+func newImageCache(inspector ImageInspector, maxEntries int, ttl time.Duration) *imageCache {
+	return &imageCache{
+		inspector:  inspector,
+		entries:    map[string]imageCacheEntry{},
+		maxEntries: maxEntries,
+		ttl:        ttl,
+	}
+}
+
+func (c *imageCache) inspect(ctx context.Context, imageID string) (image.InspectResponse, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[imageID]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.image, nil
+	}
+
+	imageJSON, err := c.inspector.ImageInspect(ctx, imageID)
+	if err != nil {
+		return imageJSON, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.maxEntries {
+		clear(c.entries)
+	}
+	c.entries[imageID] = imageCacheEntry{
+		image:     imageJSON,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+
+	return imageJSON, nil
+}
+
+func imageDetails(ctx context.Context, cache *imageCache, imageID string, md metadatax.MetadataContainer) error {
+	imageJSON, err := cache.inspect(ctx, imageID)
+	// the image of a running container can be removed
+	if cerrdefs.IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not inspect image", "imageID", imageID)
+	}
+
+	imd := md.Segment("image")
+	for _, tag := range imageJSON.RepoTags {
+		imd.AddLabel("tag", tag)
+	}
+	for _, digest := range imageJSON.RepoDigests {
+		imd.AddLabel("repo-digest", digest)
+	}
+
+	imd.AddLabel("architecture", imageJSON.Architecture)
+	imd.AddLabel("os", imageJSON.Os)
+	imd.AddLabel("variant", imageJSON.Variant)
+	imd.AddLabel("created", formatTimestamp(imageJSON.Created))
+	imd.AddLabel("size", strconv.FormatInt(imageJSON.Size, 10))
+
+	if imageJSON.Config != nil {
+		imd.AddLabel("source", imageJSON.Config.Labels["org.opencontainers.image.source"])
+		imd.AddLabel("revision", imageJSON.Config.Labels["org.opencontainers.image.revision"])
+		imd.AddLabel("version", imageJSON.Config.Labels["org.opencontainers.image.version"])
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package docker
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+
+	"github.com/gezacorp/metadatax"
+)
+
+type SwarmInspector interface {
+	ServiceInspectWithRaw(ctx context.Context, serviceID string, opts swarm.ServiceInspectOptions) (swarm.Service, []byte, error)
+	TaskInspectWithRaw(ctx context.Context, taskID string) (swarm.Task, []byte, error)
+}
+
+const (
+	defaultSwarmCacheSize = 256
+	swarmServiceCacheTTL  = time.Minute
+)
+
+type serviceCacheEntry struct {
+	service   swarm.Service
+	expiresAt time.Time
+}
+
+// swarmCache keeps service inspect results for a short while, as their spec
+// can be updated, and task inspect results, whose slot never changes. Once
+// the daemon turns out not to be a manager, it is not asked again until the
+// service TTL passes.
+type swarmCache struct {
+	inspector  SwarmInspector
+	services   map[string]serviceCacheEntry
+	tasks      map[string]swarm.Task
+	maxEntries int
+
+	notManagerUntil time.Time
+
+	mu sync.Mutex
+}
+
+func newSwarmCache(inspector SwarmInspector, maxEntries int) *swarmCache {
+	return &swarmCache{
+		inspector:  inspector,
+		services:   map[string]serviceCacheEntry{},
+		tasks:      map[string]swarm.Task{},
+		maxEntries: maxEntries,
+	}
+}
+
+func (c *swarmCache) isManager() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return time.Now().After(c.notManagerUntil)
+}
+
+func (c *swarmCache) checkManager(err error) {
+	if !isNotSwarmManager(err) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notManagerUntil = time.Now().Add(swarmServiceCacheTTL)
+}
+
+func (c *swarmCache) inspectTask(ctx context.Context, taskID string) (swarm.Task, bool, error) {
+	c.mu.Lock()
+	task, ok := c.tasks[taskID]
+	c.mu.Unlock()
+
+	if ok {
+		return task, true, nil
+	}
+
+	if !c.isManager() {
+		return task, false, nil
+	}
+
+	task, _, err := c.inspector.TaskInspectWithRaw(ctx, taskID)
+	c.checkManager(err)
+	// tasks are removed once the task history limit is reached
+	if cerrdefs.IsNotFound(err) || isNotSwarmManager(err) {
+		return task, false, nil
+	}
+
+	if err != nil {
+		return task, false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.tasks) >= c.maxEntries {
+		clear(c.tasks)
+	}
+	c.tasks[taskID] = task
+
+	return task, true, nil
+}
+
+func (c *swarmCache) inspectService(ctx context.Context, serviceID string) (swarm.Service, bool, error) {
+	c.mu.Lock()
+	entry, ok := c.services[serviceID]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.service, true, nil
+	}
+
+	if !c.isManager() {
+		return swarm.Service{}, false, nil
+	}
+
+	service, _, err := c.inspector.ServiceInspectWithRaw(ctx, serviceID, swarm.ServiceInspectOptions{})
+	c.checkManager(err)
+	if cerrdefs.IsNotFound(err) || isNotSwarmManager(err) {
+		return service, false, nil
+	}
+
+	if err != nil {
+		return service, false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.services) >= c.maxEntries {
+		clear(c.services)
+	}
+	c.services[serviceID] = serviceCacheEntry{
+		service:   service,
+		expiresAt: time.Now().Add(swarmServiceCacheTTL),
+	}
+
+	return service, true, nil
+}
+
+// isNotSwarmManager reports whether the daemon refused the request as a
+// swarm worker, which can only inspect its own containers.
+func isNotSwarmManager(err error) bool {
+	return err != nil && (cerrdefs.IsUnavailable(err) || strings.Contains(err.Error(), "not a swarm manager"))
+}
+
+func swarmDetails(ctx context.Context, cache *swarmCache, containerJSON container.InspectResponse, md metadatax.MetadataContainer) error {
+	smd := md.Segment("swarm")
+
+	if taskID := containerJSON.Config.Labels[swarmLabelPrefix+"task.id"]; taskID != "" {
+		task, found, err := cache.inspectTask(ctx, taskID)
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "could not inspect task", "taskID", taskID)
+		}
+
+		if found && task.Slot > 0 {
+			smd.AddLabel("task:slot", strconv.Itoa(task.Slot))
+		}
+	}
+
+	serviceID := containerJSON.Config.Labels[swarmLabelPrefix+"service.id"]
+	if serviceID == "" {
+		return nil
+	}
+
+	service, found, err := cache.inspectService(ctx, serviceID)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not inspect service", "serviceID", serviceID)
+	}
+
+	if !found {
+		return nil
+	}
+
+	mode := service.Spec.Mode
+	switch {
+	case mode.Replicated != nil:
+		smd.AddLabel("service:mode", "replicated")
+		if mode.Replicated.Replicas != nil {
+			smd.AddLabel("service:replicas", strconv.FormatUint(*mode.Replicated.Replicas, 10))
+		}
+	case mode.Global != nil:
+		smd.AddLabel("service:mode", "global")
+	case mode.ReplicatedJob != nil:
+		smd.AddLabel("service:mode", "replicated-job")
+		if mode.ReplicatedJob.TotalCompletions != nil {
+			smd.AddLabel("service:replicas", strconv.FormatUint(*mode.ReplicatedJob.TotalCompletions, 10))
+		}
+	case mode.GlobalJob != nil:
+		smd.AddLabel("service:mode", "global-job")
+	}
+
+	// the namespace is already known from the container labels in most cases
+	if containerJSON.Config.Labels[stackNamespaceLabel] == "" {
+		smd.AddLabel("stack:namespace", service.Spec.Labels[stackNamespaceLabel])
+	}
+
+	return nil
+}
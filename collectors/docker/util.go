@@ -3,13 +3,18 @@ package docker
 import (
 	"os"
 	"regexp"
+	"strconv"
 
 	"emperror.dev/errors"
 	"github.com/prometheus/procfs"
+
+	cgroupparser "github.com/gezacorp/metadatax/cgroups"
 )
 
 type Cgroup = procfs.Cgroup
 
+var userServiceRegex = regexp.MustCompile(`/user@(\d+)\.service(?:/|$)`)
+
 func GetProc(pid int) (procfs.Proc, error) {
 	hostProc := os.Getenv("HOST_PROC")
 	if hostProc == "" {
@@ -39,13 +44,34 @@ func GetCgroupsForPID(pid int) ([]Cgroup, error) {
 }
 
 func GetContainerIDFromCgroups(cgroups []Cgroup) string {
-	dockerCgroupRegex := regexp.MustCompile(`\b((?i)[a-z0-9]{64})`)
+	info, _ := cgroupparser.Parse(cgroupPaths(cgroups))
+
+	return info.ContainerID
+}
 
+func cgroupPaths(cgroups []Cgroup) []string {
+	paths := make([]string, 0, len(cgroups))
 	for _, cgroup := range cgroups {
-		if match := dockerCgroupRegex.FindStringSubmatch(cgroup.Path); len(match) > 0 {
-			return match[1]
+		paths = append(paths, cgroup.Path)
+	}
+
+	return paths
+}
+
+// GetRootlessUIDForPID returns the uid of the user whose systemd user
+// manager runs the cgroup of pid, which is the owner of the rootless daemon.
+// The uid of the process itself is a subordinate one in user namespaces.
+func GetRootlessUIDForPID(pid int) (int, error) {
+	cgroups, err := GetCgroupsForPID(pid)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, path := range cgroupPaths(cgroups) {
+		if m := userServiceRegex.FindStringSubmatch(path); len(m) > 1 {
+			return strconv.Atoi(m[1])
 		}
 	}
 
-	return ""
+	return 0, errors.NewPlain("could not find user service in cgroups")
 }
@@ -5,6 +5,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -17,9 +18,11 @@ import (
 type ClientOption func(*apiServerClient)
 
 type apiServerClient struct {
-	c          client.Client
-	nodeName   string
-	kubeconfig string
+	c             client.Client
+	clientset     clientset.Interface
+	nodeName      string
+	kubeconfig    string
+	maxOwnerDepth int
 }
 
 func WithKubeconfig(path string) ClientOption {
@@ -28,44 +31,91 @@ func WithKubeconfig(path string) ClientOption {
 	}
 }
 
-func NewClient(opts ...ClientOption) (kubernetes.PodLister, error) {
-	var err error
+func WithNodeName(nodeName string) ClientOption {
+	return func(c *apiServerClient) {
+		c.nodeName = nodeName
+	}
+}
+
+// WithClientset sets the clientset used by the pod cache.
+func WithClientset(cs clientset.Interface) ClientOption {
+	return func(c *apiServerClient) {
+		c.clientset = cs
+	}
+}
+
+// WithClient sets the client used for listing pods and resolving owners.
+func WithClient(cl client.Client) ClientOption {
+	return func(c *apiServerClient) {
+		c.c = cl
+	}
+}
+
+// WithMaxOwnerDepth limits the number of owner references followed when
+// resolving the top-level controller of a pod.
+func WithMaxOwnerDepth(depth int) ClientOption {
+	return func(c *apiServerClient) {
+		c.maxOwnerDepth = depth
+	}
+}
 
+func NewClient(opts ...ClientOption) (kubernetes.PodLister, error) {
 	c := &apiServerClient{}
 
 	for _, o := range opts {
 		o(c)
 	}
 
-	var cfg *rest.Config
-
-	if c.kubeconfig != "" {
-		cfg, err = clientcmd.BuildConfigFromFlags("", c.kubeconfig)
-	} else {
-		cfg, err = config.GetConfig()
+	if err := c.setNodeName(); err != nil {
+		return nil, err
 	}
 
-	if err != nil {
+	if err := c.setClient(); err != nil {
 		return nil, err
 	}
 
-	if c.nodeName, err = kubernetes.NodeName(); err != nil {
-		return nil, err
+	return c, nil
+}
+
+func (c *apiServerClient) setClient() error {
+	if c.c != nil {
+		return nil
+	}
+
+	cfg, err := c.restConfig()
+	if err != nil {
+		return err
 	}
 
 	s := runtime.NewScheme()
 	if err := scheme.AddToScheme(s); err != nil {
-		return nil, err
+		return err
 	}
 
 	c.c, err = client.New(cfg, client.Options{
 		Scheme: s,
 	})
-	if err != nil {
-		return nil, err
+
+	return err
+}
+
+func (c *apiServerClient) restConfig() (*rest.Config, error) {
+	if c.kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", c.kubeconfig)
 	}
 
-	return c, nil
+	return config.GetConfig()
+}
+
+func (c *apiServerClient) setNodeName() error {
+	if c.nodeName != "" {
+		return nil
+	}
+
+	var err error
+	c.nodeName, err = kubernetes.NodeName()
+
+	return err
 }
 
 func (c *apiServerClient) GetPods(ctx context.Context) ([]corev1.Pod, error) {
@@ -0,0 +1,88 @@
+package apiserver
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes"
+)
+
+type namespaceCache struct {
+	clientset clientset.Interface
+	informer  cache.SharedIndexInformer
+}
+
+// NewNamespaceCache returns a NamespaceGetter backed by an informer watching
+// the namespaces until ctx is done. Namespaces are read directly until the
+// informer is synced.
+func NewNamespaceCache(ctx context.Context, opts ...ClientOption) (kubernetes.NamespaceGetter, error) {
+	c := &apiServerClient{}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	if c.clientset == nil {
+		cfg, err := c.restConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		if c.clientset, err = clientset.NewForConfig(cfg); err != nil {
+			return nil, errors.WrapIf(err, "could not create clientset")
+		}
+	}
+
+	namespaces := c.clientset.CoreV1().Namespaces()
+
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return namespaces.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return namespaces.Watch(ctx, options)
+		},
+	}, &corev1.Namespace{}, 0, cache.Indexers{})
+
+	if err := informer.SetTransform(stripManagedFields); err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+
+	go informer.Run(ctx.Done())
+
+	return &namespaceCache{
+		clientset: c.clientset,
+		informer:  informer,
+	}, nil
+}
+
+func (c *namespaceCache) GetNamespace(ctx context.Context, name string) (corev1.Namespace, error) {
+	if !c.informer.HasSynced() {
+		ns, err := c.clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return corev1.Namespace{}, err
+		}
+
+		return *ns, nil
+	}
+
+	obj, found, err := c.informer.GetStore().GetByKey(name)
+	if err != nil {
+		return corev1.Namespace{}, errors.WithStackIf(err)
+	}
+
+	ns, ok := obj.(*corev1.Namespace)
+	if !found || !ok {
+		return corev1.Namespace{}, apierrors.NewNotFound(corev1.Resource("namespaces"), name)
+	}
+
+	return *ns, nil
+}
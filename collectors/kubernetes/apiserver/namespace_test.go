@@ -0,0 +1,51 @@
+package apiserver_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes/apiserver"
+)
+
+func TestNamespaceCache(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	cs := fake.NewClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "default",
+			Labels: map[string]string{"team": "platform"},
+		},
+	})
+
+	namespaceCache, err := apiserver.NewNamespaceCache(ctx, apiserver.WithClientset(cs))
+	require.NoError(t, err)
+
+	ns, err := namespaceCache.GetNamespace(ctx, "default")
+	require.NoError(t, err)
+	assert.Equal(t, "platform", ns.GetLabels()["team"])
+
+	updated := ns.DeepCopy()
+	updated.Labels["team"] = "payments"
+	_, err = cs.CoreV1().Namespaces().Update(ctx, updated, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		ns, err := namespaceCache.GetNamespace(ctx, "default")
+
+		return err == nil && ns.GetLabels()["team"] == "payments"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = namespaceCache.GetNamespace(ctx, "missing")
+	assert.True(t, apierrors.IsNotFound(err))
+}
@@ -0,0 +1,71 @@
+package apiserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes"
+)
+
+const nodeCacheTTL = time.Minute
+
+type nodeCacheEntry struct {
+	node      corev1.Node
+	expiresAt time.Time
+}
+
+type nodeGetter struct {
+	c client.Client
+
+	cache map[string]nodeCacheEntry
+	mu    sync.Mutex
+}
+
+// NewNodeGetter returns a NodeGetter caching nodes for a minute, node status
+// is reported by the kubelet about as often.
+func NewNodeGetter(opts ...ClientOption) (kubernetes.NodeGetter, error) {
+	c := &apiServerClient{}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	if err := c.setClient(); err != nil {
+		return nil, err
+	}
+
+	return &nodeGetter{
+		c:     c.c,
+		cache: map[string]nodeCacheEntry{},
+	}, nil
+}
+
+func (g *nodeGetter) GetNode(ctx context.Context, name string) (corev1.Node, error) {
+	g.mu.Lock()
+	entry, ok := g.cache[name]
+	g.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.node, nil
+	}
+
+	var node corev1.Node
+	if err := g.c.Get(ctx, client.ObjectKey{Name: name}, &node); err != nil {
+		return node, err
+	}
+	node.ManagedFields = nil
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.cache[name] = nodeCacheEntry{
+		node:      node,
+		expiresAt: time.Now().Add(nodeCacheTTL),
+	}
+
+	return node, nil
+}
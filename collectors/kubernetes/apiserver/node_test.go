@@ -0,0 +1,108 @@
+package apiserver_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes/apiserver"
+)
+
+func TestNodeGetter(t *testing.T) {
+	t.Parallel()
+
+	var gets atomic.Int32
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node-1",
+				Labels: map[string]string{corev1.LabelTopologyZone: "eu-central-1a"},
+			},
+		}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				gets.Add(1)
+
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	getter, err := apiserver.NewNodeGetter(apiserver.WithClient(cl))
+	require.NoError(t, err)
+
+	for range 2 {
+		node, err := getter.GetNode(context.Background(), "node-1")
+		require.NoError(t, err)
+		assert.Equal(t, "eu-central-1a", node.GetLabels()[corev1.LabelTopologyZone])
+	}
+	assert.Equal(t, int32(1), gets.Load())
+
+	_, err = getter.GetNode(context.Background(), "node-2")
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestNodeGetterConcurrentLookups(t *testing.T) {
+	t.Parallel()
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if key.Name == "node-2" {
+					close(entered)
+					<-release
+				}
+
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	getter, err := apiserver.NewNodeGetter(apiserver.WithClient(cl))
+	require.NoError(t, err)
+
+	_, err = getter.GetNode(context.Background(), "node-1")
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := getter.GetNode(context.Background(), "node-2")
+		done <- err
+	}()
+	<-entered
+
+	// cached nodes are served while another node is being fetched
+	cached := make(chan error)
+	go func() {
+		_, err := getter.GetNode(context.Background(), "node-1")
+		cached <- err
+	}()
+
+	select {
+	case err := <-cached:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "cached lookup waited for the pending one")
+	}
+
+	close(release)
+	assert.NoError(t, <-done)
+}
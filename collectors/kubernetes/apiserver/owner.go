@@ -0,0 +1,115 @@
+package apiserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes"
+)
+
+const (
+	defaultMaxOwnerDepth = 5
+	ownerCacheTTL        = 10 * time.Minute
+	maxOwnerCacheEntries = 1024
+)
+
+type ownerCacheEntry struct {
+	owner     metav1.OwnerReference
+	expiresAt time.Time
+}
+
+type ownerResolver struct {
+	c        client.Client
+	maxDepth int
+
+	cache map[types.UID]ownerCacheEntry
+	mu    sync.Mutex
+}
+
+// NewOwnerResolver returns an OwnerResolver following the controller
+// references of the owners, for any kind, using metadata only requests.
+// The chain ends at the last owner which could be read.
+func NewOwnerResolver(opts ...ClientOption) (kubernetes.OwnerResolver, error) {
+	c := &apiServerClient{}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	if err := c.setClient(); err != nil {
+		return nil, err
+	}
+
+	r := &ownerResolver{
+		c:        c.c,
+		maxDepth: c.maxOwnerDepth,
+		cache:    map[types.UID]ownerCacheEntry{},
+	}
+
+	if r.maxDepth <= 0 {
+		r.maxDepth = defaultMaxOwnerDepth
+	}
+
+	return r, nil
+}
+
+func (r *ownerResolver) ResolveOwner(ctx context.Context, namespace string, owner metav1.OwnerReference) (metav1.OwnerReference, error) {
+	r.mu.Lock()
+	entry, ok := r.cache[owner.UID]
+	r.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.owner, nil
+	}
+
+	current := owner
+	for range r.maxDepth {
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(current.APIVersion, current.Kind))
+
+		err := r.c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: current.Name}, obj)
+		// the owner is being deleted, or it can not be read with the RBAC
+		// permissions or the API resources at hand
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) || meta.IsNoMatchError(err) {
+			break
+		}
+
+		if err != nil {
+			return current, errors.WrapIfWithDetails(err, "could not get owner", "kind", current.Kind, "name", current.Name)
+		}
+
+		// the owner was recreated under the same name
+		if obj.GetUID() != current.UID {
+			break
+		}
+
+		next := metav1.GetControllerOfNoCopy(obj)
+		if next == nil {
+			break
+		}
+
+		current = *next
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.cache) >= maxOwnerCacheEntries {
+		clear(r.cache)
+	}
+	r.cache[owner.UID] = ownerCacheEntry{
+		owner:     current,
+		expiresAt: time.Now().Add(ownerCacheTTL),
+	}
+
+	return current, nil
+}
@@ -0,0 +1,144 @@
+package apiserver_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes/apiserver"
+)
+
+func controllerRef(apiVersion, kind, name string, uid types.UID) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       name,
+		UID:        uid,
+		Controller: ptr.To(true),
+	}
+}
+
+func TestOwnerResolver(t *testing.T) {
+	t.Parallel()
+
+	deployment := controllerRef("apps/v1", "Deployment", "nginx", "d0b2c7a4-5b8e-4f3a-9c1d-2e6f8a0b4c7d")
+	replicaSet := controllerRef("apps/v1", "ReplicaSet", "nginx-7c79c4bf97", "7d520f95-9839-40d0-8da4-73aa2c4ea8b5")
+	cronJob := controllerRef("batch/v1", "CronJob", "backup", "4f1e9a2c-8d3b-4c6e-a5f7-0b9d2e4c6a8f")
+	job := controllerRef("batch/v1", "Job", "backup-29163780", "c3a5e7b9-1d2f-4a6c-8e0b-3f5d7a9c1e2b")
+
+	var gets atomic.Int32
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: deployment.Name, Namespace: "default", UID: deployment.UID}},
+			&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+				Name: replicaSet.Name, Namespace: "default", UID: replicaSet.UID,
+				OwnerReferences: []metav1.OwnerReference{deployment},
+			}},
+			&batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: cronJob.Name, Namespace: "default", UID: cronJob.UID}},
+			&batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+				Name: job.Name, Namespace: "default", UID: job.UID,
+				OwnerReferences: []metav1.OwnerReference{cronJob},
+			}},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				gets.Add(1)
+
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	resolver, err := apiserver.NewOwnerResolver(apiserver.WithClient(cl))
+	require.NoError(t, err)
+
+	for range 2 {
+		owner, err := resolver.ResolveOwner(context.Background(), "default", replicaSet)
+		require.NoError(t, err)
+		assert.Equal(t, deployment, owner)
+	}
+	// resolved owners are cached
+	assert.Equal(t, int32(2), gets.Load())
+
+	owner, err := resolver.ResolveOwner(context.Background(), "default", job)
+	require.NoError(t, err)
+	assert.Equal(t, cronJob, owner)
+
+	// owners being deleted end the chain
+	orphan := controllerRef("apps/v1", "ReplicaSet", "gone", "9e8d7c6b-5a4f-3e2d-1c0b-a9f8e7d6c5b4")
+	owner, err = resolver.ResolveOwner(context.Background(), "default", orphan)
+	require.NoError(t, err)
+	assert.Equal(t, orphan, owner)
+
+	resolver, err = apiserver.NewOwnerResolver(apiserver.WithClient(cl), apiserver.WithMaxOwnerDepth(1))
+	require.NoError(t, err)
+
+	owner, err = resolver.ResolveOwner(context.Background(), "default", replicaSet)
+	require.NoError(t, err)
+	assert.Equal(t, deployment, owner)
+}
+
+func TestOwnerResolverInaccessibleOwners(t *testing.T) {
+	t.Parallel()
+
+	deployment := controllerRef("apps/v1", "Deployment", "nginx", "d0b2c7a4-5b8e-4f3a-9c1d-2e6f8a0b4c7d")
+	replicaSet := controllerRef("apps/v1", "ReplicaSet", "nginx-7c79c4bf97", "7d520f95-9839-40d0-8da4-73aa2c4ea8b5")
+	rollout := controllerRef("argoproj.io/v1alpha1", "Rollout", "web", "1b3d5f7a-9c2e-4a6b-8d0f-2e4a6c8e0a2c")
+	rolloutReplicaSet := controllerRef("apps/v1", "ReplicaSet", "web-5d8f9c7b6", "6a8c0e2a-4c6e-4b8d-9f1a-3c5e7a9c1e3a")
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+				Name: replicaSet.Name, Namespace: "default", UID: replicaSet.UID,
+				OwnerReferences: []metav1.OwnerReference{deployment},
+			}},
+			&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+				Name: rolloutReplicaSet.Name, Namespace: "default", UID: rolloutReplicaSet.UID,
+				OwnerReferences: []metav1.OwnerReference{rollout},
+			}},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				gvk := obj.GetObjectKind().GroupVersionKind()
+
+				switch gvk.Kind {
+				case "Deployment":
+					return apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, key.Name, errors.New("rbac"))
+				case "Rollout":
+					return &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+				}
+
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	resolver, err := apiserver.NewOwnerResolver(apiserver.WithClient(cl))
+	require.NoError(t, err)
+
+	owner, err := resolver.ResolveOwner(context.Background(), "default", replicaSet)
+	require.NoError(t, err)
+	assert.Equal(t, deployment, owner)
+
+	owner, err = resolver.ResolveOwner(context.Background(), "default", rolloutReplicaSet)
+	require.NoError(t, err)
+	assert.Equal(t, rollout, owner)
+}
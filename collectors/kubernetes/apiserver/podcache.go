@@ -0,0 +1,133 @@
+package apiserver
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes"
+)
+
+const uidIndex = "uid"
+
+type informerPodCache struct {
+	informer cache.SharedIndexInformer
+	synced   chan struct{}
+}
+
+// NewPodCache returns a PodCache backed by an informer watching the pods
+// scheduled to the node until ctx is done.
+func NewPodCache(ctx context.Context, opts ...ClientOption) (kubernetes.PodCache, error) {
+	c := &apiServerClient{}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	if c.clientset == nil {
+		cfg, err := c.restConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		if c.clientset, err = clientset.NewForConfig(cfg); err != nil {
+			return nil, errors.WrapIf(err, "could not create clientset")
+		}
+	}
+
+	if err := c.setNodeName(); err != nil {
+		return nil, err
+	}
+
+	selector := fields.OneTermEqualSelector("spec.nodeName", c.nodeName).String()
+	pods := c.clientset.CoreV1().Pods(metav1.NamespaceAll)
+
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+
+			return pods.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+
+			return pods.Watch(ctx, options)
+		},
+	}, &corev1.Pod{}, 0, cache.Indexers{
+		uidIndex: func(obj any) ([]string, error) {
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				return nil, nil
+			}
+
+			return kubernetes.PodUIDs(*pod), nil
+		},
+	})
+
+	if err := informer.SetTransform(stripManagedFields); err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+
+	pc := &informerPodCache{
+		informer: informer,
+		synced:   make(chan struct{}),
+	}
+
+	go informer.Run(ctx.Done())
+	go func() {
+		if cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+			close(pc.synced)
+		}
+	}()
+
+	return pc, nil
+}
+
+// stripManagedFields drops the managed fields of cached objects, they are not
+// used and take up most of the memory.
+func stripManagedFields(obj any) (any, error) {
+	if accessor, err := meta.Accessor(obj); err == nil {
+		accessor.SetManagedFields(nil)
+	}
+
+	return obj, nil
+}
+
+func (c *informerPodCache) GetPods(ctx context.Context) ([]corev1.Pod, error) {
+	objs := c.informer.GetStore().List()
+
+	pods := make([]corev1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			pods = append(pods, *pod)
+		}
+	}
+
+	return pods, nil
+}
+
+func (c *informerPodCache) GetPod(uid string) (corev1.Pod, bool) {
+	objs, err := c.informer.GetIndexer().ByIndex(uidIndex, uid)
+	if err != nil || len(objs) == 0 {
+		return corev1.Pod{}, false
+	}
+
+	pod, ok := objs[0].(*corev1.Pod)
+	if !ok {
+		return corev1.Pod{}, false
+	}
+
+	return *pod, true
+}
+
+func (c *informerPodCache) Synced() <-chan struct{} {
+	return c.synced
+}
@@ -0,0 +1,82 @@
+package apiserver_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes/apiserver"
+)
+
+func newPod(name string, uid types.UID) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       uid,
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+		},
+	}
+}
+
+func TestPodCache(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	etcd := newPod("etcd-node-1", "0f3a8c3e-7b1d-4c55-9a7e-2b6f1d9e4c21")
+	etcd.Annotations = map[string]string{
+		"kubernetes.io/config.hash":   "8d4e1a3b7c2f9e6d5a0b1c2d3e4f5a6b",
+		"kubernetes.io/config.mirror": "8d4e1a3b7c2f9e6d5a0b1c2d3e4f5a6b",
+		"kubernetes.io/config.source": "file",
+	}
+
+	cs := fake.NewClientset(newPod("nginx", "5831c41b-55ba-4e82-9c6e-2d3ad9d8bfe9"), etcd)
+
+	podCache, err := apiserver.NewPodCache(ctx,
+		apiserver.WithClientset(cs),
+		apiserver.WithNodeName("node-1"),
+	)
+	require.NoError(t, err)
+
+	select {
+	case <-podCache.Synced():
+	case <-time.After(5 * time.Second):
+		t.Fatal("pod cache is not synced")
+	}
+
+	pod, found := podCache.GetPod("5831c41b-55ba-4e82-9c6e-2d3ad9d8bfe9")
+	assert.True(t, found)
+	assert.Equal(t, "nginx", pod.GetName())
+
+	// static pods are found by the config hash of their mirror pod
+	pod, found = podCache.GetPod("8d4e1a3b7c2f9e6d5a0b1c2d3e4f5a6b")
+	assert.True(t, found)
+	assert.Equal(t, "etcd-node-1", pod.GetName())
+
+	_, found = podCache.GetPod("83cf03c7-a39a-482a-8b8a-fe3cf1b09e48")
+	assert.False(t, found)
+
+	_, err = cs.CoreV1().Pods("default").Create(ctx, newPod("redis", "83cf03c7-a39a-482a-8b8a-fe3cf1b09e48"), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, found := podCache.GetPod("83cf03c7-a39a-482a-8b8a-fe3cf1b09e48")
+
+		return found
+	}, 5*time.Second, 10*time.Millisecond)
+
+	pods, err := podCache.GetPods(ctx)
+	require.NoError(t, err)
+	assert.Len(t, pods, 3)
+}
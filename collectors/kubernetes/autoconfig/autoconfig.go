@@ -1,7 +1,11 @@
 package autoconfig
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"emperror.dev/errors"
 
@@ -12,6 +16,17 @@ import (
 
 var AutoConfigurationFailedErr = errors.NewPlain("auto configuration failed")
 
+const (
+	SourceEnv        = "MDX_K8S_SOURCE"
+	KubeConfigEnv    = "MDX_K8S_KUBECONFIG"
+	KubeletConfigEnv = "MDX_K8S_KUBELET_CONFIG"
+	KubeletCACertEnv = "MDX_K8S_KUBELET_CA_CERT"
+	KubeletCertEnv   = "MDX_K8S_KUBELET_CERT"
+	KubeletKeyEnv    = "MDX_K8S_KUBELET_KEY"
+
+	defaultHealthCheckTimeout = 5 * time.Second
+)
+
 type SourceType string
 
 const (
@@ -27,7 +42,15 @@ const (
 	MicroK8sProvider  Provider = "microk8s"
 	GKEProvider       Provider = "gke"
 	EKSProvider       Provider = "eks"
+	RKE2Provider      Provider = "rke2"
+	K0sProvider       Provider = "k0s"
+	OpenShiftProvider Provider = "openshift"
+	AKSProvider       Provider = "aks"
+	MinikubeProvider  Provider = "minikube"
+	TalosProvider     Provider = "talos"
+	KubeadmProvider   Provider = "kubeadm"
 	InClusterProvider Provider = "in-cluster"
+	ExplicitProvider  Provider = "explicit"
 )
 
 type Config struct {
@@ -38,6 +61,8 @@ type Config struct {
 	CACertFile        string
 	CertFile          string
 	KeyFile           string
+	// DetectFiles tell providers sharing file locations apart
+	DetectFiles []string
 }
 
 var configs = []Config{
@@ -82,11 +107,69 @@ var configs = []Config{
 		CertFile:          "/var/lib/kubelet/pki/kubelet-client.crt",
 		KeyFile:           "/var/lib/kubelet/pki/kubelet-client.key",
 	},
+	{
+		Provider:          RKE2Provider,
+		SourceType:        KubeletSourceType,
+		KubeletConfigFile: "/var/lib/rancher/rke2/agent/etc/kubelet.conf.d/00-rke2-defaults.conf",
+		CACertFile:        "/var/lib/rancher/rke2/agent/serving-kubelet.crt",
+		CertFile:          "/var/lib/rancher/rke2/agent/client-kubelet.crt",
+		KeyFile:           "/var/lib/rancher/rke2/agent/client-kubelet.key",
+	},
+	{
+		Provider:       RKE2Provider,
+		SourceType:     APIServerSourceType,
+		KubeConfigFile: "/var/lib/rancher/rke2/agent/kubelet.kubeconfig",
+	},
+	{
+		Provider:       K0sProvider,
+		SourceType:     APIServerSourceType,
+		KubeConfigFile: "/var/lib/k0s/kubelet.conf",
+	},
+	{
+		Provider:          OpenShiftProvider,
+		SourceType:        APIServerSourceType,
+		KubeletConfigFile: "/etc/kubernetes/kubelet.conf",
+		KubeConfigFile:    "/var/lib/kubelet/kubeconfig",
+		DetectFiles:       []string{"/etc/kubernetes/kubelet-ca.crt"},
+	},
+	{
+		Provider:   AKSProvider,
+		SourceType: KubeletSourceType,
+		CACertFile: "/etc/kubernetes/certs/kubeletserver.crt",
+		CertFile:   "/etc/kubernetes/certs/client.crt",
+		KeyFile:    "/etc/kubernetes/certs/client.key",
+	},
+	{
+		Provider:       AKSProvider,
+		SourceType:     APIServerSourceType,
+		KubeConfigFile: "/var/lib/kubelet/kubeconfig",
+		DetectFiles:    []string{"/etc/kubernetes/azure.json"},
+	},
 	{
 		Provider:       EKSProvider,
 		SourceType:     APIServerSourceType,
 		KubeConfigFile: "/var/lib/kubelet/kubeconfig",
 	},
+	{
+		Provider:          MinikubeProvider,
+		SourceType:        KubeletSourceType,
+		KubeletConfigFile: "/var/lib/kubelet/config.yaml",
+		CACertFile:        "/var/lib/kubelet/pki/kubelet.crt",
+		CertFile:          "/var/lib/minikube/certs/apiserver-kubelet-client.crt",
+		KeyFile:           "/var/lib/minikube/certs/apiserver-kubelet-client.key",
+	},
+	{
+		Provider:       TalosProvider,
+		SourceType:     APIServerSourceType,
+		KubeConfigFile: "/etc/kubernetes/kubeconfig-kubelet",
+		DetectFiles:    []string{"/etc/kubernetes/kubelet.yaml"},
+	},
+	{
+		Provider:       KubeadmProvider,
+		SourceType:     APIServerSourceType,
+		KubeConfigFile: "/etc/kubernetes/kubelet.conf",
+		DetectFiles:    []string{"/var/lib/kubelet/config.yaml"},
+	},
 	{
 		Provider:   InClusterProvider,
 		SourceType: APIServerSourceType,
@@ -94,31 +177,204 @@ var configs = []Config{
 	},
 }
 
-func (c Config) Available() bool {
+// Check returns why the config can not be used on this host, if at all.
+func (c Config) Check() error {
+	var paths []string
+
 	switch c.SourceType {
 	case APIServerSourceType:
 		if c.Provider == InClusterProvider {
-			return everyFileExistsAndReadable(c.CACertFile)
+			paths = append(paths, c.CACertFile)
+		} else {
+			paths = append(paths, c.KubeConfigFile)
 		}
-
-		return everyFileExistsAndReadable(c.KubeConfigFile)
 	case KubeletSourceType:
-		return everyFileExistsAndReadable(c.CACertFile, c.CertFile, c.KeyFile)
+		paths = append(paths, c.CACertFile, c.CertFile, c.KeyFile)
 	default:
-		return false
+		return errors.Errorf("unknown source type %q", c.SourceType)
+	}
+
+	for _, path := range append(paths, c.DetectFiles...) {
+		if !fileExistsAndReadable(path) {
+			return errors.Errorf("missing or unreadable file %s", path)
+		}
+	}
+
+	return nil
+}
+
+func (c Config) Available() bool {
+	return c.Check() == nil
+}
+
+// Candidate is a config considered during auto configuration, along with
+// the reason it was rejected.
+type Candidate struct {
+	Config   Config
+	Selected bool
+	Reason   string
+}
+
+// Report lists the configs considered during auto configuration.
+type Report struct {
+	Candidates []Candidate
+}
+
+func (r Report) String() string {
+	var sb strings.Builder
+	for _, c := range r.Candidates {
+		fmt.Fprintf(&sb, "%s/%s: ", c.Config.Provider, c.Config.SourceType)
+		if c.Selected {
+			sb.WriteString("selected")
+		} else {
+			sb.WriteString(c.Reason)
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+type Option func(*autoConfigurer)
+
+// WithSource limits the candidates to a source type, a provider or both,
+// given as kubelet, k3s or k3s/kubelet. Defaults to $MDX_K8S_SOURCE.
+func WithSource(source string) Option {
+	return func(a *autoConfigurer) {
+		a.source = source
+	}
+}
+
+// WithConfig adds an explicitly configured candidate, tried before the
+// well-known locations.
+func WithConfig(cfg Config) Option {
+	return func(a *autoConfigurer) {
+		a.explicit = append(a.explicit, cfg)
+	}
+}
+
+// WithConfigs replaces the well-known locations.
+func WithConfigs(configs []Config) Option {
+	return func(a *autoConfigurer) {
+		a.configs = configs
+	}
+}
+
+func WithPodListerFactory(fn func(Config) (kubernetes.PodLister, error)) Option {
+	return func(a *autoConfigurer) {
+		a.podListerFactory = fn
+	}
+}
+
+func WithHealthCheckTimeout(timeout time.Duration) Option {
+	return func(a *autoConfigurer) {
+		a.healthCheckTimeout = timeout
 	}
 }
 
-func PodLister() (kubernetes.PodLister, error) {
-	var cfg Config
-	for _, config := range configs {
-		if config.Available() {
-			cfg = config
+type autoConfigurer struct {
+	source             string
+	explicit           []Config
+	configs            []Config
+	podListerFactory   func(Config) (kubernetes.PodLister, error)
+	healthCheckTimeout time.Duration
+}
+
+// PodLister returns the pod lister of the first available config passing
+// the health check.
+func PodLister(opts ...Option) (kubernetes.PodLister, error) {
+	podLister, report, err := Discover(context.Background(), opts...)
+	if err != nil {
+		return nil, errors.WithDetails(err, "candidates", report.String())
+	}
+
+	return podLister, nil
+}
+
+// Discover tries the explicit configs, then the configs of the well-known
+// providers, and selects the first one whose pod lister can list the pods.
+// The report tells why the other candidates were rejected.
+func Discover(ctx context.Context, opts ...Option) (kubernetes.PodLister, Report, error) {
+	a := &autoConfigurer{
+		source:             os.Getenv(SourceEnv),
+		configs:            configs,
+		podListerFactory:   NewPodLister,
+		healthCheckTimeout: defaultHealthCheckTimeout,
+	}
+
+	for _, f := range opts {
+		f(a)
+	}
+
+	candidates := append(envConfigs(), a.explicit...)
+	candidates = append(candidates, a.configs...)
 
-			break
+	var report Report
+	var selected kubernetes.PodLister
+
+	for _, cfg := range candidates {
+		candidate := Candidate{Config: cfg}
+
+		switch {
+		case selected != nil:
+			candidate.Reason = "not tried, an earlier candidate was selected"
+		case !a.matchesSource(cfg):
+			candidate.Reason = fmt.Sprintf("filtered out by source %q", a.source)
+		default:
+			podLister, err := a.try(ctx, cfg)
+			if err != nil {
+				candidate.Reason = err.Error()
+			} else {
+				candidate.Selected = true
+				selected = podLister
+			}
 		}
+
+		report.Candidates = append(report.Candidates, candidate)
 	}
 
+	if selected == nil {
+		return nil, report, errors.WithStackIf(AutoConfigurationFailedErr)
+	}
+
+	return selected, report, nil
+}
+
+func (a *autoConfigurer) matchesSource(cfg Config) bool {
+	if a.source == "" {
+		return true
+	}
+
+	provider, source, found := strings.Cut(a.source, "/")
+	if found {
+		return Provider(provider) == cfg.Provider && SourceType(source) == cfg.SourceType
+	}
+
+	return SourceType(a.source) == cfg.SourceType || Provider(a.source) == cfg.Provider
+}
+
+func (a *autoConfigurer) try(ctx context.Context, cfg Config) (kubernetes.PodLister, error) {
+	if err := cfg.Check(); err != nil {
+		return nil, err
+	}
+
+	podLister, err := a.podListerFactory(cfg)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not create pod lister")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.healthCheckTimeout)
+	defer cancel()
+
+	if _, err := podLister.GetPods(ctx); err != nil {
+		return nil, errors.WrapIf(err, "health check failed")
+	}
+
+	return podLister, nil
+}
+
+// NewPodLister returns the pod lister of cfg.
+func NewPodLister(cfg Config) (kubernetes.PodLister, error) {
 	switch cfg.SourceType {
 	case APIServerSourceType:
 		opts := []apiserver.ClientOption{
@@ -133,20 +389,40 @@ func PodLister() (kubernetes.PodLister, error) {
 			kubelet.WithClientKeyPEMFile(cfg.KeyFile),
 		}
 
+		if cfg.KubeletConfigFile != "" && fileExistsAndReadable(cfg.KubeletConfigFile) {
+			opts = append(opts, kubelet.WithKubeletConfigFile(cfg.KubeletConfigFile))
+		}
+
 		return kubelet.NewClient(opts...)
 	}
 
-	return nil, errors.WithStackIf(AutoConfigurationFailedErr)
+	return nil, errors.Errorf("unknown source type %q", cfg.SourceType)
 }
 
-func everyFileExistsAndReadable(paths ...string) bool {
-	for _, path := range paths {
-		if !fileExistsAndReadable(path) {
-			return false
-		}
+// envConfigs returns the explicit configs given by environment variables.
+func envConfigs() []Config {
+	var configs []Config
+
+	if path := os.Getenv(KubeConfigEnv); path != "" {
+		configs = append(configs, Config{
+			Provider:       ExplicitProvider,
+			SourceType:     APIServerSourceType,
+			KubeConfigFile: path,
+		})
 	}
 
-	return true
+	if certFile := os.Getenv(KubeletCertEnv); certFile != "" {
+		configs = append(configs, Config{
+			Provider:          ExplicitProvider,
+			SourceType:        KubeletSourceType,
+			KubeletConfigFile: os.Getenv(KubeletConfigEnv),
+			CACertFile:        os.Getenv(KubeletCACertEnv),
+			CertFile:          certFile,
+			KeyFile:           os.Getenv(KubeletKeyEnv),
+		})
+	}
+
+	return configs
 }
 
 func fileExistsAndReadable(path string) bool {
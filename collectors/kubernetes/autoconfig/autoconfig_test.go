@@ -0,0 +1,146 @@
+package autoconfig_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes"
+	"github.com/gezacorp/metadatax/collectors/kubernetes/autoconfig"
+)
+
+type podLister struct {
+	cfg autoconfig.Config
+	err error
+}
+
+func (l *podLister) GetPods(context.Context) ([]corev1.Pod, error) {
+	return nil, l.err
+}
+
+func touch(t *testing.T, name string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	return path
+}
+
+func TestDiscover(t *testing.T) {
+	kubeconfig := touch(t, "kubeconfig")
+	unhealthy := touch(t, "unhealthy")
+
+	configs := []autoconfig.Config{
+		{
+			Provider:       autoconfig.K3sProvider,
+			SourceType:     autoconfig.APIServerSourceType,
+			KubeConfigFile: filepath.Join(t.TempDir(), "missing"),
+		},
+		{
+			Provider:       autoconfig.KindProvider,
+			SourceType:     autoconfig.APIServerSourceType,
+			KubeConfigFile: unhealthy,
+		},
+		{
+			Provider:       autoconfig.KubeadmProvider,
+			SourceType:     autoconfig.APIServerSourceType,
+			KubeConfigFile: kubeconfig,
+		},
+		{
+			Provider:   autoconfig.InClusterProvider,
+			SourceType: autoconfig.APIServerSourceType,
+			CACertFile: kubeconfig,
+		},
+	}
+
+	factory := func(cfg autoconfig.Config) (kubernetes.PodLister, error) {
+		if cfg.KubeConfigFile == unhealthy {
+			return &podLister{cfg: cfg, err: errors.New("connection refused")}, nil
+		}
+
+		return &podLister{cfg: cfg}, nil
+	}
+
+	lister, report, err := autoconfig.Discover(context.Background(),
+		autoconfig.WithConfigs(configs),
+		autoconfig.WithPodListerFactory(factory),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, autoconfig.KubeadmProvider, lister.(*podLister).cfg.Provider)
+
+	require.Len(t, report.Candidates, 4)
+	assert.Contains(t, report.Candidates[0].Reason, "missing or unreadable file")
+	assert.Contains(t, report.Candidates[1].Reason, "health check failed")
+	assert.True(t, report.Candidates[2].Selected)
+	assert.Contains(t, report.Candidates[3].Reason, "not tried")
+
+	assert.Equal(t, strings.Join([]string{
+		"k3s/apiserver: " + report.Candidates[0].Reason,
+		"kind/apiserver: " + report.Candidates[1].Reason,
+		"kubeadm/apiserver: selected",
+		"in-cluster/apiserver: " + report.Candidates[3].Reason,
+		"",
+	}, "\n"), report.String())
+
+	lister, report, err = autoconfig.Discover(context.Background(),
+		autoconfig.WithConfigs(configs),
+		autoconfig.WithPodListerFactory(factory),
+		autoconfig.WithSource("in-cluster"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, autoconfig.InClusterProvider, lister.(*podLister).cfg.Provider)
+	assert.Contains(t, report.Candidates[2].Reason, "filtered out by source")
+
+	_, _, err = autoconfig.Discover(context.Background(),
+		autoconfig.WithConfigs(configs),
+		autoconfig.WithPodListerFactory(factory),
+		autoconfig.WithSource("kubelet"),
+	)
+	assert.ErrorIs(t, err, autoconfig.AutoConfigurationFailedErr)
+}
+
+func TestDiscoverExplicit(t *testing.T) {
+	kubeconfig := touch(t, "kubeconfig")
+	cert := touch(t, "cert")
+
+	t.Setenv(autoconfig.KubeletCACertEnv, touch(t, "ca"))
+	t.Setenv(autoconfig.KubeletCertEnv, cert)
+	t.Setenv(autoconfig.KubeletKeyEnv, touch(t, "key"))
+	t.Setenv(autoconfig.SourceEnv, "explicit/kubelet")
+
+	factory := func(cfg autoconfig.Config) (kubernetes.PodLister, error) {
+		return &podLister{cfg: cfg}, nil
+	}
+
+	lister, report, err := autoconfig.Discover(context.Background(),
+		autoconfig.WithConfigs([]autoconfig.Config{
+			{
+				Provider:       autoconfig.KubeadmProvider,
+				SourceType:     autoconfig.APIServerSourceType,
+				KubeConfigFile: kubeconfig,
+			},
+		}),
+		autoconfig.WithConfig(autoconfig.Config{
+			Provider:       autoconfig.ExplicitProvider,
+			SourceType:     autoconfig.APIServerSourceType,
+			KubeConfigFile: kubeconfig,
+		}),
+		autoconfig.WithPodListerFactory(factory),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, autoconfig.KubeletSourceType, lister.(*podLister).cfg.SourceType)
+	assert.Equal(t, cert, lister.(*podLister).cfg.CertFile)
+	require.Len(t, report.Candidates, 3)
+	assert.True(t, report.Candidates[0].Selected)
+}
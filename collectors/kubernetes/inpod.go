@@ -0,0 +1,223 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/gezacorp/metadatax"
+)
+
+const (
+	defaultPodInfoDir = "/etc/podinfo"
+	defaultTokenFile  = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+type inPodCollector struct {
+	podInfoDir string
+	tokenFile  string
+
+	mdContainerInitFunc func() metadatax.MetadataContainer
+}
+
+type InPodCollectorOption func(*inPodCollector)
+
+// InPodCollectorWithPodInfoDir sets the directory of the downward API volume
+// holding the labels and annotations files.
+func InPodCollectorWithPodInfoDir(dir string) InPodCollectorOption {
+	return func(c *inPodCollector) {
+		c.podInfoDir = dir
+	}
+}
+
+func InPodCollectorWithTokenFile(path string) InPodCollectorOption {
+	return func(c *inPodCollector) {
+		c.tokenFile = path
+	}
+}
+
+func InPodCollectorWithMetadataContainerInitFunc(fn func() metadatax.MetadataContainer) InPodCollectorOption {
+	return func(c *inPodCollector) {
+		c.mdContainerInitFunc = fn
+	}
+}
+
+// NewInPodCollector returns a collector for processes running inside a pod,
+// using the downward API volume and the claims of the projected service
+// account token, without reaching the kubelet or the apiserver.
+// It always reports the pod of the collector itself, whatever PID is in the
+// context, as the files seen by other processes could claim any identity.
+func NewInPodCollector(opts ...InPodCollectorOption) metadatax.Collector {
+	c := &inPodCollector{
+		podInfoDir: defaultPodInfoDir,
+		tokenFile:  defaultTokenFile,
+	}
+
+	for _, f := range opts {
+		f(c)
+	}
+
+	if c.mdContainerInitFunc == nil {
+		c.mdContainerInitFunc = func() metadatax.MetadataContainer {
+			return metadatax.New(metadatax.WithPrefix(name))
+		}
+	}
+
+	return c
+}
+
+func (c *inPodCollector) GetMetadata(ctx context.Context) (metadatax.MetadataContainer, error) {
+	md := c.mdContainerInitFunc()
+
+	for file, segment := range map[string]string{
+		"labels":      "label",
+		"annotations": "annotation",
+	} {
+		values, err := readDownwardAPIFile(filepath.Join(c.podInfoDir, file))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not read downward api file", "file", file)
+		}
+
+		smd := md.Segment(segment)
+		for k, v := range values {
+			smd.AddLabel(k, v)
+		}
+	}
+
+	claims, err := readTokenClaims(c.tokenFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return md, nil
+	}
+
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not read service account token")
+	}
+
+	claims.metadata(md)
+
+	return md, nil
+}
+
+// readDownwardAPIFile parses the key="value" lines of a downward API file.
+func readDownwardAPIFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+
+	// values are quoted, so every line is a whole entry however long it is
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		line := strings.TrimSpace(string(line))
+		if line == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, errors.Errorf("invalid line %q", line)
+		}
+
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+type objectRef struct {
+	Name string `json:"name"`
+	UID  string `json:"uid"`
+}
+
+type tokenClaims struct {
+	Issuer     string `json:"iss"`
+	Expiry     int64  `json:"exp"`
+	Kubernetes struct {
+		Namespace      string     `json:"namespace"`
+		Pod            *objectRef `json:"pod"`
+		Node           *objectRef `json:"node"`
+		ServiceAccount objectRef  `json:"serviceaccount"`
+	} `json:"kubernetes.io"`
+
+	// claims of legacy, secret based tokens
+	LegacyNamespace          string `json:"kubernetes.io/serviceaccount/namespace"`
+	LegacyServiceAccountName string `json:"kubernetes.io/serviceaccount/service-account.name"`
+	LegacyServiceAccountUID  string `json:"kubernetes.io/serviceaccount/service-account.uid"`
+}
+
+// readTokenClaims decodes the claims of the token without verifying its
+// signature, the token is trusted as it is mounted by the kubelet.
+func readTokenClaims(path string) (tokenClaims, error) {
+	var claims tokenClaims
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return claims, err
+	}
+
+	parts := strings.Split(strings.TrimSpace(string(content)), ".")
+	if len(parts) != 3 {
+		return claims, errors.NewPlain("invalid token format")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, errors.WrapIf(err, "could not decode token payload")
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, errors.WrapIf(err, "could not unmarshal token claims")
+	}
+
+	return claims, nil
+}
+
+func (t tokenClaims) metadata(md metadatax.MetadataContainer) {
+	pmd := md.Segment("pod")
+
+	namespace, sa := t.Kubernetes.Namespace, t.Kubernetes.ServiceAccount
+	if namespace == "" {
+		namespace = t.LegacyNamespace
+		sa = objectRef{Name: t.LegacyServiceAccountName, UID: t.LegacyServiceAccountUID}
+	}
+
+	pmd.AddLabel("namespace", namespace).
+		AddLabel("serviceaccount", sa.Name)
+
+	if pod := t.Kubernetes.Pod; pod != nil {
+		pmd.AddLabel("name", pod.Name).
+			AddLabel("uid", pod.UID)
+	}
+
+	samd := pmd.Segment("serviceaccount")
+	samd.AddLabel("uid", sa.UID)
+
+	tmd := samd.Segment("token")
+	tmd.AddLabel("issuer", t.Issuer)
+	if t.Expiry > 0 {
+		tmd.AddLabel("expiry", time.Unix(t.Expiry, 0).UTC().Format(time.RFC3339))
+	}
+
+	if node := t.Kubernetes.Node; node != nil {
+		md.Segment("node").
+			AddLabel("name", node.Name).
+			AddLabel("uid", node.UID)
+	}
+}
@@ -0,0 +1,133 @@
+package kubernetes_test
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gezacorp/metadatax"
+	"github.com/gezacorp/metadatax/collectors/kubernetes"
+)
+
+func TestInPodCollector(t *testing.T) {
+	t.Parallel()
+
+	collector := kubernetes.NewInPodCollector(
+		kubernetes.InPodCollectorWithPodInfoDir("testdata/podinfo"),
+		kubernetes.InPodCollectorWithTokenFile("testdata/podinfo/token"),
+	)
+
+	expectedLabels := map[string][]string{
+		"kubernetes:annotation:kubernetes.io/config.seen":   {"2023-11-23T16:37:13.953323037Z"},
+		"kubernetes:annotation:kubernetes.io/config.source": {"api"},
+		"kubernetes:annotation:note":                        {"multi\nline \"value\""},
+		"kubernetes:label:k8s-app":                          {"metrics-server"},
+		"kubernetes:label:pod-template-hash":                {"648b5df564"},
+		"kubernetes:node:name":                              {"lima-k3s"},
+		"kubernetes:node:uid":                               {"b2c1d0e9-8f7a-4b6c-9d5e-4f3a2b1c0d9e"},
+		"kubernetes:pod:name":                               {"metrics-server-648b5df564-drsb2"},
+		"kubernetes:pod:namespace":                          {"kube-system"},
+		"kubernetes:pod:serviceaccount":                     {"metrics-server"},
+		"kubernetes:pod:serviceaccount:token:expiry":        {"2026-05-12T08:00:00Z"},
+		"kubernetes:pod:serviceaccount:token:issuer":        {"https://kubernetes.default.svc.cluster.local"},
+		"kubernetes:pod:serviceaccount:uid":                 {"1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f"},
+		"kubernetes:pod:uid":                                {"5831c41b-55ba-4e82-9c6e-2d3ad9d8bfe9"},
+	}
+
+	md, err := collector.GetMetadata(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedLabels, map[string][]string(md.GetLabels()))
+}
+
+func TestInPodCollectorWithLegacyToken(t *testing.T) {
+	t.Parallel()
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{` +
+		`"iss":"kubernetes/serviceaccount",` +
+		`"kubernetes.io/serviceaccount/namespace":"default",` +
+		`"kubernetes.io/serviceaccount/service-account.name":"builder",` +
+		`"kubernetes.io/serviceaccount/service-account.uid":"7e6d5c4b-3a2f-4e1d-9c8b-7a6f5e4d3c2b"` +
+		`}`))
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("e30."+payload+".c2ln"), 0o600))
+
+	collector := kubernetes.NewInPodCollector(
+		kubernetes.InPodCollectorWithPodInfoDir(t.TempDir()),
+		kubernetes.InPodCollectorWithTokenFile(tokenFile),
+	)
+
+	md, err := collector.GetMetadata(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"kubernetes:pod:namespace":                   {"default"},
+		"kubernetes:pod:serviceaccount":              {"builder"},
+		"kubernetes:pod:serviceaccount:token:issuer": {"kubernetes/serviceaccount"},
+		"kubernetes:pod:serviceaccount:uid":          {"7e6d5c4b-3a2f-4e1d-9c8b-7a6f5e4d3c2b"},
+	}, map[string][]string(md.GetLabels()))
+}
+
+func TestInPodCollectorWithoutFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	collector := kubernetes.NewInPodCollector(
+		kubernetes.InPodCollectorWithPodInfoDir(dir),
+		kubernetes.InPodCollectorWithTokenFile(filepath.Join(dir, "token")),
+	)
+
+	md, err := collector.GetMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, md.GetLabels())
+}
+
+func TestInPodCollectorWithLongValue(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	config := strings.Repeat("x", 128*1024)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "annotations"), []byte(`last-applied-configuration="`+config+`"`+"\n"+`note="short"`), 0o600))
+
+	collector := kubernetes.NewInPodCollector(
+		kubernetes.InPodCollectorWithPodInfoDir(dir),
+		kubernetes.InPodCollectorWithTokenFile(filepath.Join(dir, "token")),
+	)
+
+	md, err := collector.GetMetadata(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"kubernetes:annotation:last-applied-configuration": {config},
+		"kubernetes:annotation:note":                       {"short"},
+	}, map[string][]string(md.GetLabels()))
+}
+
+func TestInPodCollectorIgnoresPID(t *testing.T) {
+	procDir := t.TempDir()
+	t.Setenv("HOST_PROC", procDir)
+
+	// files in the root of another process do not describe it
+	podInfoDir := filepath.Join(procDir, "4343", "root", "etc", "podinfo")
+	require.NoError(t, os.MkdirAll(podInfoDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(podInfoDir, "labels"), []byte(`app="web"`), 0o600))
+
+	collector := kubernetes.NewInPodCollector(
+		kubernetes.InPodCollectorWithPodInfoDir("testdata/podinfo"),
+		kubernetes.InPodCollectorWithTokenFile("testdata/podinfo/token"),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 4343))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"metrics-server"}, md.GetLabels()["kubernetes:label:k8s-app"])
+	assert.NotContains(t, md.GetLabels(), "kubernetes:label:app")
+}
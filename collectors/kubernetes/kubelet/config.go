@@ -0,0 +1,64 @@
+package kubelet
+
+import (
+	"os"
+
+	"emperror.dev/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultPort         = 10250
+	defaultReadOnlyPort = 10255
+)
+
+// KubeletConfiguration holds the fields of the kubelet.config.k8s.io
+// KubeletConfiguration used to reach the kubelet API.
+type KubeletConfiguration struct {
+	Address        string                      `json:"address,omitempty"`
+	Port           int                         `json:"port,omitempty"`
+	ReadOnlyPort   int                         `json:"readOnlyPort,omitempty"`
+	TLSCertFile    string                      `json:"tlsCertFile,omitempty"`
+	Authentication KubeletAuthenticationConfig `json:"authentication,omitempty"`
+}
+
+type KubeletAuthenticationConfig struct {
+	X509 struct {
+		ClientCAFile string `json:"clientCAFile,omitempty"`
+	} `json:"x509,omitempty"`
+	Webhook struct {
+		Enabled *bool `json:"enabled,omitempty"`
+	} `json:"webhook,omitempty"`
+	Anonymous struct {
+		Enabled *bool `json:"enabled,omitempty"`
+	} `json:"anonymous,omitempty"`
+}
+
+// LoadKubeletConfiguration reads a KubeletConfiguration file in YAML or JSON
+// format.
+func LoadKubeletConfiguration(path string) (KubeletConfiguration, error) {
+	var cfg KubeletConfiguration
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, errors.WrapIfWithDetails(err, "could not read kubelet configuration", "path", path)
+	}
+
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return cfg, errors.WrapIfWithDetails(err, "could not parse kubelet configuration", "path", path)
+	}
+
+	return cfg, nil
+}
+
+// AnonymousAuthEnabled reports whether the kubelet accepts requests without
+// credentials. The kubelet configuration file defaults to disabled.
+func (c KubeletConfiguration) AnonymousAuthEnabled() bool {
+	return c.Authentication.Anonymous.Enabled != nil && *c.Authentication.Anonymous.Enabled
+}
+
+// WebhookAuthEnabled reports whether bearer tokens are accepted. The kubelet
+// configuration file defaults to enabled.
+func (c KubeletConfiguration) WebhookAuthEnabled() bool {
+	return c.Authentication.Webhook.Enabled == nil || *c.Authentication.Webhook.Enabled
+}
@@ -0,0 +1,144 @@
+package kubelet
+
+import (
+	"encoding/json"
+	"io"
+
+	"emperror.dev/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const defaultMaxResponseSize = 128 << 20
+
+var ResponseTooLargeError = errors.Sentinel("response is too large")
+
+// decodePods decodes the items of a PodList one by one, so only the pods are
+// kept in memory, never the whole response.
+func decodePods(r io.Reader, trim bool) ([]corev1.Pod, error) {
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	var pods []corev1.Pod
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, errors.WithStackIf(err)
+		}
+
+		if key, _ := token.(string); key != "items" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, errors.WithStackIf(err)
+			}
+
+			continue
+		}
+
+		token, err = dec.Token()
+		if err != nil {
+			return nil, errors.WithStackIf(err)
+		}
+
+		// empty lists might be encoded as null
+		if token == nil {
+			continue
+		}
+
+		if token != json.Delim('[') {
+			return nil, errors.Errorf("unexpected token %v, expected %v", token, json.Delim('['))
+		}
+
+		for dec.More() {
+			var pod corev1.Pod
+			if err := dec.Decode(&pod); err != nil {
+				return nil, errors.WrapIf(err, "could not decode pod")
+			}
+
+			if trim {
+				trimPod(&pod)
+			}
+
+			pods = append(pods, pod)
+		}
+
+		if err := expectDelim(dec, ']'); err != nil {
+			return nil, err
+		}
+	}
+
+	return pods, expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return errors.WithStackIf(err)
+	}
+
+	if token != delim {
+		return errors.Errorf("unexpected token %v, expected %v", token, delim)
+	}
+
+	return nil
+}
+
+// trimPod drops the fields not used by the collector.
+func trimPod(pod *corev1.Pod) {
+	pod.ManagedFields = nil
+	pod.Spec.Volumes = nil
+	pod.Spec.Affinity = nil
+	pod.Spec.Tolerations = nil
+
+	for i := range pod.Spec.Containers {
+		trimContainer(&pod.Spec.Containers[i])
+	}
+	for i := range pod.Spec.InitContainers {
+		trimContainer(&pod.Spec.InitContainers[i])
+	}
+	for i := range pod.Spec.EphemeralContainers {
+		container := corev1.Container(pod.Spec.EphemeralContainers[i].EphemeralContainerCommon)
+		trimContainer(&container)
+		pod.Spec.EphemeralContainers[i].EphemeralContainerCommon = corev1.EphemeralContainerCommon(container)
+	}
+}
+
+func trimContainer(container *corev1.Container) {
+	container.Command = nil
+	container.Args = nil
+	container.Env = nil
+	container.EnvFrom = nil
+	container.VolumeMounts = nil
+	container.VolumeDevices = nil
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	container.Lifecycle = nil
+}
+
+// limitedReader fails instead of returning EOF once more than n bytes are
+// read, unlike io.LimitReader.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ResponseTooLargeError
+	}
+
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ResponseTooLargeError
+	}
+
+	return n, err
+}
@@ -4,10 +4,9 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
-	"fmt"
-	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"emperror.dev/errors"
@@ -18,7 +17,7 @@ import (
 )
 
 const (
-	defaultAddress = "127.0.0.1:10250"
+	defaultHost = "127.0.0.1"
 )
 
 type HTTPClient interface {
@@ -87,6 +86,37 @@ func WithSkipCertVerify() ClientOption {
 	}
 }
 
+// WithKubeletConfigFile reads the address, ports and authentication settings
+// of the kubelet from its KubeletConfiguration file.
+func WithKubeletConfigFile(path string) ClientOption {
+	return func(c *kubeletClient) {
+		c.kubeletConfigFilePath = path
+	}
+}
+
+// WithReadOnlyPort uses the unauthenticated read-only HTTP port of the
+// kubelet. It is also used when the kubelet configuration enables it and no
+// credentials are given.
+func WithReadOnlyPort() ClientOption {
+	return func(c *kubeletClient) {
+		c.readOnly = true
+	}
+}
+
+// WithTrimmedPods drops the fields of the pods not used by the collector,
+// like volumes, environment variables and probes, right after decoding.
+func WithTrimmedPods() ClientOption {
+	return func(c *kubeletClient) {
+		c.trimPods = true
+	}
+}
+
+func WithMaxResponseSize(size int64) ClientOption {
+	return func(c *kubeletClient) {
+		c.maxResponseSize = size
+	}
+}
+
 type kubeletClient struct {
 	httpClient            HTTPClient
 	accessToken           string
@@ -98,7 +128,12 @@ type kubeletClient struct {
 	clientCertPEMFilePath string
 	clientKeyPEMFilePath  string
 	skipCertVerify        bool
+	kubeletConfigFilePath string
+	readOnly              bool
+	trimPods              bool
+	maxResponseSize       int64
 
+	kubeletConfig     *KubeletConfiguration
 	caPEMFile         kubernetes.CachedFile
 	clientCertPEMFile kubernetes.CachedFile
 	clientKeyPEMFile  kubernetes.CachedFile
@@ -112,12 +147,24 @@ func NewClient(opts ...ClientOption) (kubernetes.PodLister, error) {
 		f(c)
 	}
 
-	if c.address == "" {
-		if hn, err := kubernetes.NodeName(); err == nil {
-			c.address = fmt.Sprintf("%s:10250", hn)
-		} else {
-			c.address = defaultAddress
+	if c.kubeletConfigFilePath != "" {
+		cfg, err := LoadKubeletConfiguration(c.kubeletConfigFilePath)
+		if err != nil {
+			return nil, err
 		}
+		c.kubeletConfig = &cfg
+
+		if err := c.applyKubeletConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.address == "" {
+		c.address = c.defaultAddress()
+	}
+
+	if c.maxResponseSize <= 0 {
+		c.maxResponseSize = defaultMaxResponseSize
 	}
 
 	if c.caPEMFilePath != "" {
@@ -152,6 +199,10 @@ func NewClient(opts ...ClientOption) (kubernetes.PodLister, error) {
 		}
 	}
 
+	if c.httpClient == nil && c.readOnly {
+		c.httpClient = &http.Client{}
+	}
+
 	if c.httpClient == nil {
 		if err := c.setHTTPClient(); err != nil {
 			return nil, errors.WithStackIf(err)
@@ -161,6 +212,62 @@ func NewClient(opts ...ClientOption) (kubernetes.PodLister, error) {
 	return c, nil
 }
 
+func (c *kubeletClient) hasCredentials() bool {
+	return c.accessToken != "" || c.accessTokenFile != "" || c.clientCertPEM != nil || c.clientCertPEMFilePath != ""
+}
+
+func (c *kubeletClient) applyKubeletConfig() error {
+	cfg := c.kubeletConfig
+
+	if !c.hasCredentials() && !cfg.AnonymousAuthEnabled() && cfg.ReadOnlyPort > 0 {
+		c.readOnly = true
+	}
+
+	if c.readOnly {
+		return nil
+	}
+
+	if c.clientCertPEM == nil && c.clientCertPEMFilePath == "" && c.hasCredentials() && !cfg.WebhookAuthEnabled() {
+		return errors.NewPlain("kubelet does not accept bearer tokens, webhook authentication is disabled")
+	}
+
+	// the serving certificate is self-signed unless server TLS bootstrap is used
+	if c.caPEM == nil && c.caPEMFilePath == "" {
+		c.caPEMFilePath = cfg.TLSCertFile
+	}
+
+	return nil
+}
+
+// defaultAddress uses the node name unless the kubelet is bound to a
+// specific address.
+func (c *kubeletClient) defaultAddress() string {
+	host := defaultHost
+	if hn, err := kubernetes.NodeName(); err == nil {
+		host = hn
+	}
+
+	port := defaultPort
+	if c.readOnly {
+		port = defaultReadOnlyPort
+	}
+
+	if cfg := c.kubeletConfig; cfg != nil {
+		if ip := net.ParseIP(cfg.Address); ip != nil && !ip.IsUnspecified() {
+			host = cfg.Address
+		}
+
+		switch {
+		case c.readOnly && cfg.ReadOnlyPort > 0:
+			port = cfg.ReadOnlyPort
+		case !c.readOnly && cfg.Port > 0:
+			port = cfg.Port
+		}
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
 func (c *kubeletClient) GetPods(ctx context.Context) ([]corev1.Pod, error) {
 	var err error
 
@@ -169,12 +276,17 @@ func (c *kubeletClient) GetPods(ctx context.Context) ([]corev1.Pod, error) {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+c.address+"/pods", nil)
+	scheme := "https"
+	if c.readOnly {
+		scheme = "http"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+c.address+"/pods", nil)
 	if err != nil {
 		return nil, errors.WrapIf(err, "could not instantiate http request")
 	}
 
-	if c.accessToken != "" {
+	if c.accessToken != "" && !c.readOnly {
 		req.Header.Set("Authorization", "Bearer "+c.accessToken)
 	}
 
@@ -188,17 +300,12 @@ func (c *kubeletClient) GetPods(ctx context.Context) ([]corev1.Pod, error) {
 		return nil, errors.Errorf("non-200 response status: %s", resp.Status)
 	}
 
-	content, err := io.ReadAll(resp.Body)
+	pods, err := decodePods(&limitedReader{r: resp.Body, n: c.maxResponseSize}, c.trimPods)
 	if err != nil {
-		return nil, errors.WrapIf(err, "could not read response")
-	}
-
-	var pods corev1.PodList
-	if err := json.Unmarshal(content, &pods); err != nil {
-		return nil, errors.WrapIf(err, "could not unmarshal response")
+		return nil, errors.WrapIf(err, "could not decode response")
 	}
 
-	return pods.Items, nil
+	return pods, nil
 }
 
 func (c *kubeletClient) getCAPEM() ([]byte, bool, error) {
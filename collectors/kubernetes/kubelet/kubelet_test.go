@@ -0,0 +1,139 @@
+package kubelet_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes/kubelet"
+)
+
+func startFakeKubelet(t *testing.T, podsFile string) (string, string) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pods" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		http.ServeFile(w, r, podsFile)
+	}))
+	t.Cleanup(server.Close)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	return host, port
+}
+
+func writeKubeletConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadKubeletConfiguration(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := kubelet.LoadKubeletConfiguration(writeKubeletConfig(t, `
+apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+address: 10.0.0.12
+port: 10260
+readOnlyPort: 10255
+tlsCertFile: /var/lib/kubelet/pki/kubelet.crt
+authentication:
+  anonymous:
+    enabled: false
+  webhook:
+    enabled: true
+  x509:
+    clientCAFile: /etc/kubernetes/pki/ca.crt
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "10.0.0.12", cfg.Address)
+	assert.Equal(t, 10260, cfg.Port)
+	assert.Equal(t, 10255, cfg.ReadOnlyPort)
+	assert.Equal(t, "/var/lib/kubelet/pki/kubelet.crt", cfg.TLSCertFile)
+	assert.Equal(t, "/etc/kubernetes/pki/ca.crt", cfg.Authentication.X509.ClientCAFile)
+	assert.False(t, cfg.AnonymousAuthEnabled())
+	assert.True(t, cfg.WebhookAuthEnabled())
+}
+
+func TestGetPodsReadOnlyPort(t *testing.T) {
+	t.Parallel()
+
+	host, port := startFakeKubelet(t, "../testdata/pods.json")
+
+	client, err := kubelet.NewClient(
+		kubelet.WithKubeletConfigFile(writeKubeletConfig(t, "address: "+host+"\nreadOnlyPort: "+port+"\n")),
+		kubelet.WithTrimmedPods(),
+	)
+	require.NoError(t, err)
+
+	pods, err := client.GetPods(context.Background())
+	require.NoError(t, err)
+	require.Len(t, pods, 2)
+
+	assert.Equal(t, "metrics-server-648b5df564-drsb2", pods[0].GetName())
+	assert.Equal(t, "metrics-server", pods[0].Spec.Containers[0].Name)
+	assert.NotEmpty(t, pods[0].Status.ContainerStatuses)
+	assert.Empty(t, pods[0].Spec.Volumes)
+	assert.Empty(t, pods[0].Spec.Containers[0].VolumeMounts)
+	assert.Empty(t, pods[0].ManagedFields)
+}
+
+func TestGetPodsWithoutPods(t *testing.T) {
+	t.Parallel()
+
+	host, port := startFakeKubelet(t, "../testdata/no-pods.json")
+
+	client, err := kubelet.NewClient(
+		kubelet.WithAddress(net.JoinHostPort(host, port)),
+		kubelet.WithReadOnlyPort(),
+	)
+	require.NoError(t, err)
+
+	pods, err := client.GetPods(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, pods)
+}
+
+func TestGetPodsMaxResponseSize(t *testing.T) {
+	t.Parallel()
+
+	host, port := startFakeKubelet(t, "../testdata/pods.json")
+
+	client, err := kubelet.NewClient(
+		kubelet.WithAddress(net.JoinHostPort(host, port)),
+		kubelet.WithReadOnlyPort(),
+		kubelet.WithMaxResponseSize(1024),
+	)
+	require.NoError(t, err)
+
+	_, err = client.GetPods(context.Background())
+	assert.ErrorIs(t, err, kubelet.ResponseTooLargeError)
+}
+
+func TestNewClientWebhookAuthDisabled(t *testing.T) {
+	t.Parallel()
+
+	_, err := kubelet.NewClient(
+		kubelet.WithKubeletConfigFile(writeKubeletConfig(t, "authentication:\n  webhook:\n    enabled: false\n")),
+		kubelet.WithAccessToken("token"),
+	)
+	assert.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package kubelet
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	podresourcesv1 "k8s.io/kubelet/pkg/apis/podresources/v1"
+
+	"github.com/gezacorp/metadatax/collectors/kubernetes"
+)
+
+const (
+	DefaultPodResourcesSocketPath = "unix:///var/lib/kubelet/pod-resources/kubelet.sock"
+	DefaultPodResourcesCacheTTL   = 5 * time.Second
+)
+
+var PodResourcesNotFoundError = kubernetes.PodResourcesNotFoundError
+
+type PodResourcesClientOption func(*podResourcesClient)
+
+func WithPodResourcesSocketPath(socketPath string) PodResourcesClientOption {
+	return func(c *podResourcesClient) {
+		c.socketPath = socketPath
+	}
+}
+
+func WithPodResourcesGRPCDialOpts(opts ...grpc.DialOption) PodResourcesClientOption {
+	return func(c *podResourcesClient) {
+		c.grpcDialOpts = opts
+	}
+}
+
+// WithPodResourcesCacheTTL sets how long a listing of the pod resources is
+// reused.
+func WithPodResourcesCacheTTL(ttl time.Duration) PodResourcesClientOption {
+	return func(c *podResourcesClient) {
+		c.cacheTTL = ttl
+	}
+}
+
+type podResourcesClient struct {
+	socketPath   string
+	grpcDialOpts []grpc.DialOption
+	cacheTTL     time.Duration
+
+	client podresourcesv1.PodResourcesListerClient
+
+	resources []*podresourcesv1.PodResources
+	listedAt  time.Time
+	mu        sync.Mutex
+}
+
+// NewPodResourcesClient returns a PodResourcesGetter using the kubelet
+// PodResources API served on a unix socket.
+func NewPodResourcesClient(opts ...PodResourcesClientOption) (kubernetes.PodResourcesGetter, error) {
+	c := &podResourcesClient{
+		socketPath: DefaultPodResourcesSocketPath,
+		cacheTTL:   DefaultPodResourcesCacheTTL,
+	}
+
+	for _, f := range opts {
+		f(c)
+	}
+
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, c.grpcDialOpts...)
+
+	conn, err := grpc.NewClient("unix://"+strings.TrimPrefix(c.socketPath, "unix://"), dialOpts...)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not create grpc client")
+	}
+
+	c.client = podresourcesv1.NewPodResourcesListerClient(conn)
+
+	return c, nil
+}
+
+// GetPodResources lists the resources of every pod, since the Get method is
+// behind a feature gate of the kubelet. The listing is reused for a short
+// while, unless the pod is missing from it.
+func (c *podResourcesClient) GetPodResources(ctx context.Context, namespace, name string) (*podresourcesv1.PodResources, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.listedAt) < c.cacheTTL {
+		if resources := findPodResources(c.resources, namespace, name); resources != nil {
+			return resources, nil
+		}
+	}
+
+	resp, err := c.client.List(ctx, &podresourcesv1.ListPodResourcesRequest{})
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not list pod resources")
+	}
+
+	c.resources = resp.GetPodResources()
+	c.listedAt = time.Now()
+
+	if resources := findPodResources(c.resources, namespace, name); resources != nil {
+		return resources, nil
+	}
+
+	return nil, errors.WithDetails(PodResourcesNotFoundError, "namespace", namespace, "name", name)
+}
+
+func findPodResources(list []*podresourcesv1.PodResources, namespace, name string) *podresourcesv1.PodResources {
+	for _, resources := range list {
+		if resources.GetNamespace() == namespace && resources.GetName() == name {
+			return resources
+		}
+	}
+
+	return nil
+}
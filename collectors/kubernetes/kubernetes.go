@@ -2,7 +2,8 @@ package kubernetes
 
 import (
 	"context"
-	"regexp"
+	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -10,9 +11,12 @@ import (
 
 	"emperror.dev/errors"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/cenkalti/backoff/v5"
 	"github.com/gezacorp/metadatax"
+	cgroupparser "github.com/gezacorp/metadatax/cgroups"
 )
 
 const (
@@ -29,6 +33,16 @@ type PodLister interface {
 	GetPods(ctx context.Context) ([]corev1.Pod, error)
 }
 
+// OwnerResolver returns the top-level controller of an owner reference. On
+// errors it returns the last owner it could resolve.
+type OwnerResolver interface {
+	ResolveOwner(ctx context.Context, namespace string, owner metav1.OwnerReference) (metav1.OwnerReference, error)
+}
+
+type NamespaceGetter interface {
+	GetNamespace(ctx context.Context, name string) (corev1.Namespace, error)
+}
+
 type podContext struct {
 	pod             corev1.Pod
 	container       corev1.Container
@@ -36,8 +50,15 @@ type podContext struct {
 }
 
 type collector struct {
-	podLister   PodLister
-	podResolver PodResolver
+	podLister     PodLister
+	podResolver   PodResolver
+	ownerResolver OwnerResolver
+
+	nodeGetter              NodeGetter
+	podResourcesGetter      PodResourcesGetter
+	namespaceGetter         NamespaceGetter
+	namespaceLabelKeys      []string
+	namespaceAnnotationKeys []string
 
 	mdContainerInitFunc func() metadatax.MetadataContainer
 	skipOnSoftError     bool
@@ -61,6 +82,52 @@ func WithPodResolver(resolver PodResolver) CollectorOption {
 	}
 }
 
+// WithOwnerResolver adds the top-level controller of the pod as workload.
+func WithOwnerResolver(resolver OwnerResolver) CollectorOption {
+	return func(c *collector) {
+		c.ownerResolver = resolver
+	}
+}
+
+// WithNodeGetter adds the details of the node of the pod.
+func WithNodeGetter(getter NodeGetter) CollectorOption {
+	return func(c *collector) {
+		c.nodeGetter = getter
+	}
+}
+
+// WithPodResourcesGetter adds the devices, CPUs and memory the kubelet
+// allocated to the container.
+func WithPodResourcesGetter(getter PodResourcesGetter) CollectorOption {
+	return func(c *collector) {
+		c.podResourcesGetter = getter
+	}
+}
+
+// WithNamespaceGetter adds the labels and annotations of the pod namespace.
+func WithNamespaceGetter(getter NamespaceGetter) CollectorOption {
+	return func(c *collector) {
+		c.namespaceGetter = getter
+	}
+}
+
+// WithNamespaceLabelKeys limits the namespace labels to the keys matching any
+// of the path.Match patterns. Every label is added by default.
+func WithNamespaceLabelKeys(patterns ...string) CollectorOption {
+	return func(c *collector) {
+		c.namespaceLabelKeys = append(c.namespaceLabelKeys, patterns...)
+	}
+}
+
+// WithNamespaceAnnotationKeys limits the namespace annotations to the keys
+// matching any of the path.Match patterns. Every annotation is added by
+// default.
+func WithNamespaceAnnotationKeys(patterns ...string) CollectorOption {
+	return func(c *collector) {
+		c.namespaceAnnotationKeys = append(c.namespaceAnnotationKeys, patterns...)
+	}
+}
+
 func CollectorWithMetadataContainerInitFunc(fn func() metadatax.MetadataContainer) CollectorOption {
 	return func(c *collector) {
 		c.mdContainerInitFunc = fn
@@ -125,21 +192,24 @@ func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContaine
 		return md, nil
 	}
 
-	pods, err := c.getPods(ctx, false)
+	podctx, found, err := c.lookupPodContext(ctx, podID, containerID, false)
+	if errors.Is(err, PodCacheNotSyncedError) && c.skipOnSoftError {
+		return md, nil
+	}
+
 	if err != nil {
 		return nil, errors.WrapIf(err, "could not get pods")
 	}
 
-	podctx, found := c.getPodContext(podID, containerID, pods)
 	// try again with cache refresh
 	if !found {
 		if pc, err := backoff.Retry(ctx, func() (*podContext, error) {
-			pods, err := c.getPods(ctx, true)
+			var err error
+			podctx, found, err = c.lookupPodContext(ctx, podID, containerID, true)
 			if err != nil {
 				return nil, err
 			}
 
-			podctx, found = c.getPodContext(podID, containerID, pods)
 			if !found {
 				return nil, errors.NewPlain("pod context not found")
 			}
@@ -162,15 +232,98 @@ func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContaine
 		c.labels,
 		c.annotations,
 		c.images,
+		c.podSpec,
+		c.containerSpec,
 	}
 
 	for _, f := range getters {
 		f(podctx, md)
 	}
 
+	if c.ownerResolver != nil {
+		if err := c.workload(ctx, podctx, md); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.nodeGetter != nil {
+		if err := c.node(ctx, podctx, md); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.namespaceGetter != nil {
+		if err := c.namespace(ctx, podctx, md); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.podResourcesGetter != nil {
+		if err := c.allocatedResources(ctx, podctx, md); err != nil {
+			return nil, err
+		}
+	}
+
 	return md, nil
 }
 
+// podCacheRefresher is implemented by pod caches which can be asked to
+// catch up on a miss, instead of only keeping themselves up to date.
+type podCacheRefresher interface {
+	refresh(ctx context.Context) error
+}
+
+// lookupPodContext finds the pod context using the index of a PodCache, or
+// in the pod list otherwise. Refreshing applies to the pod list and to pod
+// caches which are not kept up to date by watching.
+func (c *collector) lookupPodContext(ctx context.Context, podID, containerID string, refresh bool) (podContext, bool, error) {
+	cache, ok := c.podLister.(PodCache)
+	if !ok {
+		pods, err := c.getPods(ctx, refresh)
+		if err != nil {
+			return podContext{}, false, err
+		}
+
+		podctx, found := c.getPodContext(podID, containerID, pods)
+
+		return podctx, found, nil
+	}
+
+	refresher, canRefresh := cache.(podCacheRefresher)
+
+	select {
+	case <-cache.Synced():
+	default:
+		// the initial listing might have failed
+		if canRefresh {
+			_ = refresher.refresh(ctx)
+		}
+	}
+
+	select {
+	case <-cache.Synced():
+	case <-ctx.Done():
+		return podContext{}, false, ctx.Err()
+	case <-time.After(c.retryMaxElapsedTime):
+		return podContext{}, false, errors.WithStack(PodCacheNotSyncedError)
+	}
+
+	if canRefresh && refresh {
+		if err := refresher.refresh(ctx); err != nil {
+			return podContext{}, false, errors.WrapIf(err, "could not refresh pod cache")
+		}
+	}
+
+	pod, found := cache.GetPod(podID)
+	if !found {
+		return podContext{}, false, nil
+	}
+
+	podctx, found := c.getContainerContext(pod, containerID)
+
+	return podctx, found, nil
+}
+
 func (c *collector) getPods(ctx context.Context, skipCache bool) ([]corev1.Pod, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -194,6 +347,10 @@ func (c *collector) pod(podctx podContext, md metadatax.MetadataContainer) {
 		AddLabel("namespace", pod.GetNamespace()).
 		AddLabel("serviceaccount", pod.Spec.ServiceAccountName)
 
+	if IsStaticPod(pod) {
+		pmd.AddLabel("static", "true")
+	}
+
 	omd := pmd.Segment("owner")
 	for _, owner := range pod.GetOwnerReferences() {
 		omd.AddLabel("kind", strings.ToLower(owner.Kind)).
@@ -204,6 +361,74 @@ func (c *collector) pod(podctx podContext, md metadatax.MetadataContainer) {
 	md.Segment("node").AddLabel("name", pod.Spec.NodeName)
 }
 
+func (c *collector) workload(ctx context.Context, podctx podContext, md metadatax.MetadataContainer) error {
+	owner := metav1.GetControllerOfNoCopy(&podctx.pod)
+	if owner == nil {
+		return nil
+	}
+
+	workload, err := c.ownerResolver.ResolveOwner(ctx, podctx.pod.GetNamespace(), *owner)
+	if err != nil && !c.skipOnSoftError {
+		return errors.WrapIfWithDetails(err, "could not resolve pod owner", "owner", owner.Name)
+	}
+
+	md.Segment("workload").
+		AddLabel("kind", strings.ToLower(workload.Kind)).
+		AddLabel("name", workload.Name).
+		AddLabel("apiversion", workload.APIVersion)
+
+	return nil
+}
+
+func (c *collector) namespace(ctx context.Context, podctx podContext, md metadatax.MetadataContainer) error {
+	ns, err := c.namespaceGetter.GetNamespace(ctx, podctx.pod.GetNamespace())
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+		return nil
+	}
+
+	if err != nil {
+		if c.skipOnSoftError {
+			return nil
+		}
+
+		return errors.WrapIfWithDetails(err, "could not get namespace", "namespace", podctx.pod.GetNamespace())
+	}
+
+	nmd := md.Segment("namespace")
+
+	lmd := nmd.Segment("label")
+	for k, v := range ns.GetLabels() {
+		if matchesAnyKey(c.namespaceLabelKeys, k) {
+			lmd.AddLabel(k, v)
+		}
+	}
+
+	amd := nmd.Segment("annotation")
+	for k, v := range ns.GetAnnotations() {
+		if matchesAnyKey(c.namespaceAnnotationKeys, k) {
+			amd.AddLabel(k, v)
+		}
+	}
+
+	return nil
+}
+
+// matchesAnyKey reports whether key matches any of the patterns, or whether
+// there are no patterns at all.
+func matchesAnyKey(patterns []string, key string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c *collector) container(podctx podContext, md metadatax.MetadataContainer) {
 	cmd := md.Segment("container")
 	cmd.AddLabel("name", podctx.container.Name)
@@ -263,16 +488,14 @@ func (c *collector) images(podctx podContext, md metadatax.MetadataContainer) {
 }
 
 func (c *collector) GetPodAndContainerID(pid int32) (string, string, error) {
-	k8sPodContainerIDRegex := regexp.MustCompile(`([a-z0-9/.-]+)?([/-]pod)?((?i)[a-z0-9-_]{36}).*((?i)[a-z0-9]{64})`)
-
 	cgroups, err := GetCgroupsForPID(int(pid))
 	if err != nil {
 		return "", "", errors.WrapIf(err, "could not get cgroups for pid")
 	}
 
-	for _, cgroup := range cgroups {
-		if match := k8sPodContainerIDRegex.FindStringSubmatch(cgroup.Path); len(match) > 0 && len(match[3]) == 36 && len(match[4]) == 64 {
-			return strings.ReplaceAll(match[3], "_", "-"), match[4], nil
+	for _, path := range cgroupPaths(cgroups) {
+		if info, ok := cgroupparser.ParsePath(path); ok && info.PodUID != "" {
+			return info.PodUID, info.ContainerID, nil
 		}
 	}
 
@@ -315,21 +538,25 @@ func (c *collector) extractContainerID(containerID string) string {
 }
 
 func (c *collector) getPodContext(podID, containerID string, pods []corev1.Pod) (podContext, bool) {
-	podContext := podContext{}
+	for _, pod := range pods {
+		if slices.Contains(PodUIDs(pod), podID) {
+			return c.getContainerContext(pod, containerID)
+		}
+	}
 
-	for _, _pod := range pods {
-		if string(_pod.GetUID()) == podID {
-			podContext.pod = _pod
+	return podContext{}, false
+}
 
-			break
-		}
+func (c *collector) getContainerContext(pod corev1.Pod, containerID string) (podContext, bool) {
+	podContext := podContext{
+		pod: pod,
 	}
 
 	if podContext.pod.GetName() == "" {
 		return podContext, false
 	}
 
-    expected := len(podContext.pod.Spec.Containers) + len(podContext.pod.Spec.InitContainers) + len(podContext.pod.Spec.EphemeralContainers)
+	expected := len(podContext.pod.Spec.Containers) + len(podContext.pod.Spec.InitContainers) + len(podContext.pod.Spec.EphemeralContainers)
 	statuses := map[string]corev1.ContainerStatus{}
 
 	for _, csc := range [][]corev1.ContainerStatus{
@@ -4,10 +4,17 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/gezacorp/metadatax"
 	"github.com/gezacorp/metadatax/collectors/kubernetes"
@@ -27,6 +34,88 @@ func (c *kubeletClient) GetPods(ctx context.Context) ([]corev1.Pod, error) {
 	return pods.Items, nil
 }
 
+type countingKubeletClient struct {
+	kubeletClient
+	calls atomic.Int32
+}
+
+func (c *countingKubeletClient) GetPods(ctx context.Context) ([]corev1.Pod, error) {
+	c.calls.Add(1)
+
+	return c.kubeletClient.GetPods(ctx)
+}
+
+// unavailableKubeletClient fails the listings until it is made available.
+type unavailableKubeletClient struct {
+	kubeletClient
+	available atomic.Bool
+}
+
+func (c *unavailableKubeletClient) GetPods(ctx context.Context) ([]corev1.Pod, error) {
+	if !c.available.Load() {
+		return nil, errors.New("connection refused")
+	}
+
+	return c.kubeletClient.GetPods(ctx)
+}
+
+// startingKubeletClient has no pods on the first listing.
+type startingKubeletClient struct {
+	kubeletClient
+	calls atomic.Int32
+}
+
+func (c *startingKubeletClient) GetPods(ctx context.Context) ([]corev1.Pod, error) {
+	if c.calls.Add(1) == 1 {
+		return nil, nil
+	}
+
+	return c.kubeletClient.GetPods(ctx)
+}
+
+type ownerResolver struct{}
+
+func (r *ownerResolver) ResolveOwner(ctx context.Context, namespace string, owner metav1.OwnerReference) (metav1.OwnerReference, error) {
+	return metav1.OwnerReference{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "metrics-server",
+	}, nil
+}
+
+type failingOwnerResolver struct{}
+
+func (r *failingOwnerResolver) ResolveOwner(ctx context.Context, namespace string, owner metav1.OwnerReference) (metav1.OwnerReference, error) {
+	return owner, errors.New("connection refused")
+}
+
+type failingNamespaceGetter struct {
+	err error
+}
+
+func (g *failingNamespaceGetter) GetNamespace(ctx context.Context, name string) (corev1.Namespace, error) {
+	return corev1.Namespace{}, g.err
+}
+
+type namespaceGetter struct{}
+
+func (g *namespaceGetter) GetNamespace(ctx context.Context, name string) (corev1.Namespace, error) {
+	return corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"kubernetes.io/metadata.name":        name,
+				"pod-security.kubernetes.io/enforce": "privileged",
+				"team":                               "platform",
+			},
+			Annotations: map[string]string{
+				"owner": "platform@example.com",
+				"scheduler.alpha.kubernetes.io/node-selector": "role=system",
+			},
+		},
+	}, nil
+}
+
 type podResolver struct{}
 
 func (r *podResolver) GetPodAndContainerID(pid int32) (string, string, error) {
@@ -37,25 +126,40 @@ func TestGetMetadata(t *testing.T) {
 	t.Parallel()
 
 	expectedLabels := map[string][]string{
-		"kubernetes:annotation:kubernetes.io/config.seen":   {"2023-11-23T16:37:13.953323037Z"},
-		"kubernetes:annotation:kubernetes.io/config.source": {"api"},
-		"kubernetes:container:image:id":                     {"docker.io/rancher/mirrored-metrics-server@sha256:c2dfd72bafd6406ed306d9fbd07f55c496b004293d13d3de88a4567eacc36558"},
-		"kubernetes:container:name":                         {"metrics-server"},
-		"kubernetes:label:k8s-app":                          {"metrics-server"},
-		"kubernetes:label:pod-template-hash":                {"648b5df564"},
-		"kubernetes:node:name":                              {"lima-k3s"},
-		"kubernetes:pod:ephemeral-image:count":              {"0"},
-		"kubernetes:pod:image:count":                        {"1"},
-		"kubernetes:pod:image:id":                           {"docker.io/rancher/mirrored-metrics-server@sha256:c2dfd72bafd6406ed306d9fbd07f55c496b004293d13d3de88a4567eacc36558"},
-		"kubernetes:pod:image:name":                         {"rancher/mirrored-metrics-server:v0.6.3"},
-		"kubernetes:pod:init-image:count":                   {"1"},
-		"kubernetes:pod:init-image:name":                    {"golang:1.24.0-alpine"},
-		"kubernetes:pod:name":                               {"metrics-server-648b5df564-drsb2"},
-		"kubernetes:pod:namespace":                          {"kube-system"},
-		"kubernetes:pod:owner:name":                         {"metrics-server-648b5df564"},
-		"kubernetes:pod:owner:kind":                         {"replicaset"},
-		"kubernetes:pod:owner:kind-with-version":            {"apps/v1/replicaset"},
-		"kubernetes:pod:serviceaccount":                     {"metrics-server"},
+		"kubernetes:annotation:kubernetes.io/config.seen":          {"2023-11-23T16:37:13.953323037Z"},
+		"kubernetes:annotation:kubernetes.io/config.source":        {"api"},
+		"kubernetes:container:image:id":                            {"docker.io/rancher/mirrored-metrics-server@sha256:c2dfd72bafd6406ed306d9fbd07f55c496b004293d13d3de88a4567eacc36558"},
+		"kubernetes:container:name":                                {"metrics-server"},
+		"kubernetes:container:port":                                {"10250/tcp"},
+		"kubernetes:container:resources:requests:cpu":              {"100m"},
+		"kubernetes:container:resources:requests:memory":           {"70Mi"},
+		"kubernetes:container:restart-count":                       {"25"},
+		"kubernetes:container:security:allow-privilege-escalation": {"false"},
+		"kubernetes:container:security:read-only-root-filesystem":  {"true"},
+		"kubernetes:container:security:run-as-non-root":            {"true"},
+		"kubernetes:container:security:run-as-user":                {"1000"},
+		"kubernetes:label:k8s-app":                                 {"metrics-server"},
+		"kubernetes:label:pod-template-hash":                       {"648b5df564"},
+		"kubernetes:node:name":                                     {"lima-k3s"},
+		"kubernetes:pod:ephemeral-image:count":                     {"0"},
+		"kubernetes:pod:host-ip":                                   {"192.168.5.15"},
+		"kubernetes:pod:host-ipc":                                  {"false"},
+		"kubernetes:pod:host-network":                              {"false"},
+		"kubernetes:pod:host-pid":                                  {"false"},
+		"kubernetes:pod:ip":                                        {"10.42.0.20"},
+		"kubernetes:pod:priority-class":                            {"system-node-critical"},
+		"kubernetes:pod:qos-class":                                 {"Burstable"},
+		"kubernetes:pod:image:count":                               {"1"},
+		"kubernetes:pod:image:id":                                  {"docker.io/rancher/mirrored-metrics-server@sha256:c2dfd72bafd6406ed306d9fbd07f55c496b004293d13d3de88a4567eacc36558"},
+		"kubernetes:pod:image:name":                                {"rancher/mirrored-metrics-server:v0.6.3"},
+		"kubernetes:pod:init-image:count":                          {"1"},
+		"kubernetes:pod:init-image:name":                           {"golang:1.24.0-alpine"},
+		"kubernetes:pod:name":                                      {"metrics-server-648b5df564-drsb2"},
+		"kubernetes:pod:namespace":                                 {"kube-system"},
+		"kubernetes:pod:owner:name":                                {"metrics-server-648b5df564"},
+		"kubernetes:pod:owner:kind":                                {"replicaset"},
+		"kubernetes:pod:owner:kind-with-version":                   {"apps/v1/replicaset"},
+		"kubernetes:pod:serviceaccount":                            {"metrics-server"},
 	}
 
 	collector := kubernetes.New(
@@ -85,25 +189,38 @@ func TestGetMetadataForInitContainer(t *testing.T) {
 	t.Parallel()
 
 	expectedLabels := map[string][]string{
-		"kubernetes:annotation:kubernetes.io/config.seen":   {"2023-11-23T16:37:13.953323037Z"},
-		"kubernetes:annotation:kubernetes.io/config.source": {"api"},
-		"kubernetes:container:image:id":                     {"docker.io/library/golang@sha256:2d40d4fc278dad38be0777d5e2a88a2c6dee51b0b29c97a764fc6c6a11ca893c"},
-		"kubernetes:container:name":                         {"alpine"},
-		"kubernetes:label:k8s-app":                          {"metrics-server"},
-		"kubernetes:label:pod-template-hash":                {"648b5df564"},
-		"kubernetes:node:name":                              {"lima-k3s"},
-		"kubernetes:pod:ephemeral-image:count":              {"0"},
-		"kubernetes:pod:image:count":                        {"1"},
-		"kubernetes:pod:image:id":                           {"docker.io/rancher/mirrored-metrics-server@sha256:c2dfd72bafd6406ed306d9fbd07f55c496b004293d13d3de88a4567eacc36558"},
-		"kubernetes:pod:image:name":                         {"rancher/mirrored-metrics-server:v0.6.3"},
-		"kubernetes:pod:init-image:count":                   {"1"},
-		"kubernetes:pod:init-image:name":                    {"golang:1.24.0-alpine"},
-		"kubernetes:pod:name":                               {"metrics-server-648b5df564-drsb2"},
-		"kubernetes:pod:namespace":                          {"kube-system"},
-		"kubernetes:pod:owner:name":                         {"metrics-server-648b5df564"},
-		"kubernetes:pod:owner:kind":                         {"replicaset"},
-		"kubernetes:pod:owner:kind-with-version":            {"apps/v1/replicaset"},
-		"kubernetes:pod:serviceaccount":                     {"metrics-server"},
+		"kubernetes:annotation:kubernetes.io/config.seen":          {"2023-11-23T16:37:13.953323037Z"},
+		"kubernetes:annotation:kubernetes.io/config.source":        {"api"},
+		"kubernetes:container:image:id":                            {"docker.io/library/golang@sha256:2d40d4fc278dad38be0777d5e2a88a2c6dee51b0b29c97a764fc6c6a11ca893c"},
+		"kubernetes:container:name":                                {"alpine"},
+		"kubernetes:container:restart-count":                       {"57"},
+		"kubernetes:container:security:allow-privilege-escalation": {"true"},
+		"kubernetes:container:security:privileged":                 {"true"},
+		"kubernetes:container:security:read-only-root-filesystem":  {"false"},
+		"kubernetes:container:security:run-as-non-root":            {"false"},
+		"kubernetes:container:security:run-as-user":                {"0"},
+		"kubernetes:label:k8s-app":                                 {"metrics-server"},
+		"kubernetes:label:pod-template-hash":                       {"648b5df564"},
+		"kubernetes:node:name":                                     {"lima-k3s"},
+		"kubernetes:pod:ephemeral-image:count":                     {"0"},
+		"kubernetes:pod:host-ip":                                   {"192.168.5.15"},
+		"kubernetes:pod:host-ipc":                                  {"false"},
+		"kubernetes:pod:host-network":                              {"false"},
+		"kubernetes:pod:host-pid":                                  {"false"},
+		"kubernetes:pod:ip":                                        {"10.42.0.20"},
+		"kubernetes:pod:priority-class":                            {"system-node-critical"},
+		"kubernetes:pod:qos-class":                                 {"Burstable"},
+		"kubernetes:pod:image:count":                               {"1"},
+		"kubernetes:pod:image:id":                                  {"docker.io/rancher/mirrored-metrics-server@sha256:c2dfd72bafd6406ed306d9fbd07f55c496b004293d13d3de88a4567eacc36558"},
+		"kubernetes:pod:image:name":                                {"rancher/mirrored-metrics-server:v0.6.3"},
+		"kubernetes:pod:init-image:count":                          {"1"},
+		"kubernetes:pod:init-image:name":                           {"golang:1.24.0-alpine"},
+		"kubernetes:pod:name":                                      {"metrics-server-648b5df564-drsb2"},
+		"kubernetes:pod:namespace":                                 {"kube-system"},
+		"kubernetes:pod:owner:name":                                {"metrics-server-648b5df564"},
+		"kubernetes:pod:owner:kind":                                {"replicaset"},
+		"kubernetes:pod:owner:kind-with-version":                   {"apps/v1/replicaset"},
+		"kubernetes:pod:serviceaccount":                            {"metrics-server"},
 	}
 
 	collector := kubernetes.New(
@@ -127,5 +244,207 @@ func TestGetMetadataForFailedContainer(t *testing.T) {
 
 	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
 	assert.EqualErrorf(t, err, "could not get pod context after timeout: pod context not found", "error message %s")
+}
+
+func TestGetMetadataWithPollingPodCache(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	lister := &countingKubeletClient{}
+	podCache := kubernetes.NewPollingPodCache(ctx, lister, kubernetes.WithPollInterval(time.Hour))
+
+	collector := kubernetes.New(
+		kubernetes.WithPodLister(podCache),
+		kubernetes.WithPodResolver(&podResolver{}),
+	)
+
+	for range 2 {
+		md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"metrics-server-648b5df564-drsb2"}, md.GetLabels()["kubernetes:pod:name"])
+	}
+
+	// misses right after a poll do not list the pods again
+	collector = kubernetes.New(
+		kubernetes.WithPodLister(podCache),
+		kubernetes.WithPodResolver(&failedContainerPodResolver{}),
+		kubernetes.WithRetryMaxElapsedTime(100*time.Millisecond),
+	)
+
+	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.EqualError(t, err, "could not get pod context after timeout: pod context not found")
+
+	assert.Equal(t, int32(1), lister.calls.Load())
+}
+
+func TestGetMetadataWithUnsyncedPollingPodCache(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	lister := &unavailableKubeletClient{}
+	podCache := kubernetes.NewPollingPodCache(ctx, lister,
+		kubernetes.WithPollInterval(time.Hour),
+		kubernetes.WithMinRefreshInterval(0),
+	)
+
+	collector := kubernetes.New(
+		kubernetes.WithPodLister(podCache),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithRetryMaxElapsedTime(100*time.Millisecond),
+	)
+
+	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.ErrorIs(t, err, kubernetes.PodCacheNotSyncedError)
+
+	md, err := kubernetes.New(
+		kubernetes.WithPodLister(podCache),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithRetryMaxElapsedTime(100*time.Millisecond),
+		kubernetes.WithSkipOnSoftError(),
+	).GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Nil(t, err)
+	assert.Empty(t, md.GetLabels())
+
+	// lookups list the pods themselves until the cache is synced
+	lister.available.Store(true)
+
+	md, err = collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"metrics-server-648b5df564-drsb2"}, md.GetLabels()["kubernetes:pod:name"])
+}
+
+func TestGetMetadataWithPollingPodCacheRefresh(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
 
+	lister := &startingKubeletClient{}
+	podCache := kubernetes.NewPollingPodCache(ctx, lister,
+		kubernetes.WithPollInterval(time.Hour),
+		kubernetes.WithMinRefreshInterval(0),
+	)
+
+	collector := kubernetes.New(
+		kubernetes.WithPodLister(podCache),
+		kubernetes.WithPodResolver(&podResolver{}),
+	)
+
+	// the pod started after the first poll is found by listing the pods on the miss
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"metrics-server-648b5df564-drsb2"}, md.GetLabels()["kubernetes:pod:name"])
+
+	assert.Equal(t, int32(2), lister.calls.Load())
+}
+
+func TestGetMetadataWithOwnerResolver(t *testing.T) {
+	t.Parallel()
+
+	collector := kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithOwnerResolver(&ownerResolver{}),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Nil(t, err)
+
+	labels := md.GetLabels()
+	assert.Equal(t, []string{"deployment"}, labels["kubernetes:workload:kind"])
+	assert.Equal(t, []string{"metrics-server"}, labels["kubernetes:workload:name"])
+	assert.Equal(t, []string{"apps/v1"}, labels["kubernetes:workload:apiversion"])
+	assert.Equal(t, []string{"replicaset"}, labels["kubernetes:pod:owner:kind"])
+}
+
+func TestGetMetadataWithFailingOwnerResolver(t *testing.T) {
+	t.Parallel()
+
+	collector := kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithOwnerResolver(&failingOwnerResolver{}),
+	)
+
+	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.ErrorContains(t, err, "could not resolve pod owner")
+
+	collector = kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithOwnerResolver(&failingOwnerResolver{}),
+		kubernetes.WithSkipOnSoftError(),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Nil(t, err)
+
+	// the last resolved owner is reported
+	labels := md.GetLabels()
+	assert.Equal(t, []string{"replicaset"}, labels["kubernetes:workload:kind"])
+	assert.Equal(t, []string{"metrics-server-648b5df564"}, labels["kubernetes:workload:name"])
+}
+
+func TestGetMetadataWithNamespaceGetter(t *testing.T) {
+	t.Parallel()
+
+	collector := kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithNamespaceGetter(&namespaceGetter{}),
+		kubernetes.WithNamespaceLabelKeys("team", "pod-security.kubernetes.io/*"),
+		kubernetes.WithNamespaceAnnotationKeys("owner"),
+	)
+
+	expectedLabels := map[string][]string{
+		"kubernetes:namespace:annotation:owner":                         {"platform@example.com"},
+		"kubernetes:namespace:label:pod-security.kubernetes.io/enforce": {"privileged"},
+		"kubernetes:namespace:label:team":                               {"platform"},
+	}
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Nil(t, err)
+
+	labels := map[string][]string{}
+	for k, v := range md.GetLabels() {
+		if strings.HasPrefix(k, "kubernetes:namespace:") {
+			labels[k] = v
+		}
+	}
+	assert.Equal(t, expectedLabels, labels)
+}
+
+func TestGetMetadataWithFailingNamespaceGetter(t *testing.T) {
+	t.Parallel()
+
+	// namespaces which cannot be read have no metadata
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "kube-system", errors.New("rbac"))
+	md, err := kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithNamespaceGetter(&failingNamespaceGetter{err: forbidden}),
+	).GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"metrics-server-648b5df564-drsb2"}, md.GetLabels()["kubernetes:pod:name"])
+
+	getter := &failingNamespaceGetter{err: errors.New("connection refused")}
+	_, err = kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithNamespaceGetter(getter),
+	).GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Error(t, err)
+
+	md, err = kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithNamespaceGetter(getter),
+		kubernetes.WithSkipOnSoftError(),
+	).GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"metrics-server-648b5df564-drsb2"}, md.GetLabels()["kubernetes:pod:name"])
 }
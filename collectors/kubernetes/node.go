@@ -0,0 +1,186 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+
+	"emperror.dev/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/gezacorp/metadatax"
+)
+
+type NodeGetter interface {
+	GetNode(ctx context.Context, name string) (corev1.Node, error)
+}
+
+type nodeCollector struct {
+	nodeGetter NodeGetter
+	nodeName   string
+
+	mdContainerInitFunc func() metadatax.MetadataContainer
+}
+
+type NodeCollectorOption func(*nodeCollector)
+
+func NodeCollectorWithNodeGetter(getter NodeGetter) NodeCollectorOption {
+	return func(c *nodeCollector) {
+		c.nodeGetter = getter
+	}
+}
+
+func NodeCollectorWithNodeName(name string) NodeCollectorOption {
+	return func(c *nodeCollector) {
+		c.nodeName = name
+	}
+}
+
+func NodeCollectorWithMetadataContainerInitFunc(fn func() metadatax.MetadataContainer) NodeCollectorOption {
+	return func(c *nodeCollector) {
+		c.mdContainerInitFunc = fn
+	}
+}
+
+// NewNodeCollector returns a collector adding the details of the node the
+// process runs on, independently of pods.
+func NewNodeCollector(opts ...NodeCollectorOption) metadatax.Collector {
+	c := &nodeCollector{}
+
+	for _, f := range opts {
+		f(c)
+	}
+
+	if c.mdContainerInitFunc == nil {
+		c.mdContainerInitFunc = func() metadatax.MetadataContainer {
+			return metadatax.New(metadatax.WithPrefix(name))
+		}
+	}
+
+	return c
+}
+
+func (c *nodeCollector) GetMetadata(ctx context.Context) (metadatax.MetadataContainer, error) {
+	md := c.mdContainerInitFunc()
+
+	if c.nodeGetter == nil {
+		return md, errors.NewPlain("node getter is not specified")
+	}
+
+	nodeName := c.nodeName
+	if nodeName == "" {
+		var err error
+		if nodeName, err = NodeName(); err != nil {
+			return nil, errors.WrapIf(err, "could not get node name")
+		}
+	}
+
+	node, err := c.nodeGetter.GetNode(ctx, nodeName)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not get node", "node", nodeName)
+	}
+
+	nmd := md.Segment("node")
+	nmd.AddLabel("name", node.GetName())
+	nodeDetails(node, nmd)
+
+	return md, nil
+}
+
+func (c *collector) node(ctx context.Context, podctx podContext, md metadatax.MetadataContainer) error {
+	nodeName := podctx.pod.Spec.NodeName
+	if nodeName == "" {
+		return nil
+	}
+
+	node, err := c.nodeGetter.GetNode(ctx, nodeName)
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+		return nil
+	}
+
+	if err != nil {
+		if c.skipOnSoftError {
+			return nil
+		}
+
+		return errors.WrapIfWithDetails(err, "could not get node", "node", nodeName)
+	}
+
+	nodeDetails(node, md.Segment("node"))
+
+	return nil
+}
+
+var nodeTopologyLabels = map[string][]string{
+	"zone":          {corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone},
+	"region":        {corev1.LabelTopologyRegion, corev1.LabelFailureDomainBetaRegion},
+	"instance-type": {corev1.LabelInstanceTypeStable, corev1.LabelInstanceType},
+}
+
+func nodeDetails(node corev1.Node, md metadatax.MetadataContainer) {
+	for key, labels := range nodeTopologyLabels {
+		for _, label := range labels {
+			if v := node.GetLabels()[label]; v != "" {
+				md.AddLabel(key, v)
+
+				break
+			}
+		}
+	}
+
+	providerID(node.Spec.ProviderID, md.Segment("provider"))
+
+	amd := md.Segment("allocatable")
+	for resource, quantity := range node.Status.Allocatable {
+		amd.AddLabel(string(resource), quantity.String())
+	}
+
+	cmd := md.Segment("capacity")
+	for resource, quantity := range node.Status.Capacity {
+		cmd.AddLabel(string(resource), quantity.String())
+	}
+
+	md.AddLabel("kubelet-version", node.Status.NodeInfo.KubeletVersion)
+	md.AddLabel("container-runtime-version", node.Status.NodeInfo.ContainerRuntimeVersion)
+
+	for _, taint := range node.Spec.Taints {
+		md.AddLabel("taint", taint.ToString())
+	}
+
+	cdmd := md.Segment("condition")
+	for _, condition := range node.Status.Conditions {
+		cdmd.AddLabel(string(condition.Type), string(condition.Status))
+	}
+}
+
+// providerID splits provider ids like aws:///us-east-1a/i-0abc or
+// gce://project/us-central1-a/instance into their parts.
+func providerID(id string, md metadatax.MetadataContainer) {
+	cloud, rest, found := strings.Cut(id, "://")
+	if !found {
+		return
+	}
+
+	var segments []string
+	for _, s := range strings.Split(rest, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+
+	md.AddLabel("cloud", cloud)
+	if len(segments) == 0 {
+		return
+	}
+
+	md.AddLabel("instance", segments[len(segments)-1])
+
+	// other providers use their own layouts, like azure resource ids
+	switch {
+	case cloud == "aws" && len(segments) == 2:
+		md.AddLabel("zone", segments[0])
+	case cloud == "gce" && len(segments) == 3:
+		md.AddLabel("project", segments[0])
+		md.AddLabel("zone", segments[1])
+	}
+}
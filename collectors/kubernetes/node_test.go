@@ -0,0 +1,167 @@
+package kubernetes_test
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gezacorp/metadatax"
+	"github.com/gezacorp/metadatax/collectors/kubernetes"
+)
+
+//go:embed testdata/node.json
+var testNodeJSON []byte
+
+type nodeGetter struct{}
+
+func (g *nodeGetter) GetNode(ctx context.Context, name string) (corev1.Node, error) {
+	var node corev1.Node
+	if err := json.Unmarshal(testNodeJSON, &node); err != nil {
+		return node, err
+	}
+
+	return node, nil
+}
+
+func TestNodeCollector(t *testing.T) {
+	t.Parallel()
+
+	expectedLabels := map[string][]string{
+		"kubernetes:node:allocatable:cpu":           {"2"},
+		"kubernetes:node:allocatable:memory":        {"7824308Ki"},
+		"kubernetes:node:allocatable:pods":          {"110"},
+		"kubernetes:node:capacity:cpu":              {"2"},
+		"kubernetes:node:capacity:memory":           {"8029108Ki"},
+		"kubernetes:node:capacity:pods":             {"110"},
+		"kubernetes:node:condition:MemoryPressure":  {"False"},
+		"kubernetes:node:condition:Ready":           {"True"},
+		"kubernetes:node:container-runtime-version": {"containerd://1.7.7-k3s1"},
+		"kubernetes:node:instance-type":             {"m7g.large"},
+		"kubernetes:node:kubelet-version":           {"v1.28.4+k3s2"},
+		"kubernetes:node:name":                      {"lima-k3s"},
+		"kubernetes:node:provider:cloud":            {"aws"},
+		"kubernetes:node:provider:instance":         {"i-0f1e2d3c4b5a69788"},
+		"kubernetes:node:provider:zone":             {"eu-central-1a"},
+		"kubernetes:node:region":                    {"eu-central-1"},
+		"kubernetes:node:taint":                     {"dedicated=metrics:NoSchedule"},
+		"kubernetes:node:zone":                      {"eu-central-1a"},
+	}
+
+	collector := kubernetes.NewNodeCollector(
+		kubernetes.NodeCollectorWithNodeGetter(&nodeGetter{}),
+		kubernetes.NodeCollectorWithNodeName("lima-k3s"),
+	)
+
+	md, err := collector.GetMetadata(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedLabels, map[string][]string(md.GetLabels()))
+}
+
+func TestGetMetadataWithNodeGetter(t *testing.T) {
+	t.Parallel()
+
+	collector := kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithNodeGetter(&nodeGetter{}),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	require.NoError(t, err)
+
+	labels := md.GetLabels()
+	assert.Equal(t, []string{"lima-k3s"}, labels["kubernetes:node:name"])
+	assert.Equal(t, []string{"eu-central-1a"}, labels["kubernetes:node:zone"])
+	assert.Equal(t, []string{"True"}, labels["kubernetes:node:condition:Ready"])
+}
+
+func TestGetMetadataWithFailingNodeGetter(t *testing.T) {
+	t.Parallel()
+
+	// nodes which cannot be read have no metadata
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "lima-k3s", errors.New("rbac"))
+	md, err := kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithNodeGetter(nodeGetterFunc(func(ctx context.Context, name string) (corev1.Node, error) {
+			return corev1.Node{}, forbidden
+		})),
+	).GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metrics-server-648b5df564-drsb2"}, md.GetLabels()["kubernetes:pod:name"])
+
+	getter := nodeGetterFunc(func(ctx context.Context, name string) (corev1.Node, error) {
+		return corev1.Node{}, errors.New("connection refused")
+	})
+	_, err = kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithNodeGetter(getter),
+	).GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Error(t, err)
+
+	md, err = kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithNodeGetter(getter),
+		kubernetes.WithSkipOnSoftError(),
+	).GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metrics-server-648b5df564-drsb2"}, md.GetLabels()["kubernetes:pod:name"])
+}
+
+func TestNodeProviderID(t *testing.T) {
+	t.Parallel()
+
+	for providerID, expectedLabels := range map[string]map[string][]string{
+		"gce://my-project/us-central1-a/gke-pool-1-abcd": {
+			"kubernetes:node:provider:cloud":    {"gce"},
+			"kubernetes:node:provider:instance": {"gke-pool-1-abcd"},
+			"kubernetes:node:provider:project":  {"my-project"},
+			"kubernetes:node:provider:zone":     {"us-central1-a"},
+		},
+		"azure:///subscriptions/0000/resourceGroups/mc_rg/providers/Microsoft.Compute/virtualMachineScaleSets/aks-pool/virtualMachines/0": {
+			"kubernetes:node:provider:cloud":    {"azure"},
+			"kubernetes:node:provider:instance": {"0"},
+		},
+		"kind://docker/kind/kind-control-plane": {
+			"kubernetes:node:provider:cloud":    {"kind"},
+			"kubernetes:node:provider:instance": {"kind-control-plane"},
+		},
+		"k3s://lima-k3s": {
+			"kubernetes:node:provider:cloud":    {"k3s"},
+			"kubernetes:node:provider:instance": {"lima-k3s"},
+		},
+	} {
+		t.Run(providerID, func(t *testing.T) {
+			t.Parallel()
+
+			collector := kubernetes.NewNodeCollector(
+				kubernetes.NodeCollectorWithNodeGetter(nodeGetterFunc(func(ctx context.Context, name string) (corev1.Node, error) {
+					return corev1.Node{Spec: corev1.NodeSpec{ProviderID: providerID}}, nil
+				})),
+				kubernetes.NodeCollectorWithNodeName("node"),
+			)
+
+			md, err := collector.GetMetadata(context.Background())
+			require.NoError(t, err)
+
+			assert.Equal(t, expectedLabels, map[string][]string(md.GetLabels()))
+		})
+	}
+}
+
+type nodeGetterFunc func(ctx context.Context, name string) (corev1.Node, error)
+
+func (f nodeGetterFunc) GetNode(ctx context.Context, name string) (corev1.Node, error) {
+	return f(ctx, name)
+}
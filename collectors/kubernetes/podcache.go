@@ -0,0 +1,186 @@
+package kubernetes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/cenkalti/backoff/v5"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultPollInterval       = 5 * time.Minute
+	defaultMinRefreshInterval = time.Second
+)
+
+var PodCacheNotSyncedError = errors.Sentinel("pod cache is not synced")
+
+// PodCache is a PodLister keeping a UID indexed view of the pods of the node
+// up to date in the background.
+type PodCache interface {
+	PodLister
+	GetPod(uid string) (corev1.Pod, bool)
+	// Synced is closed once the initial set of pods is known.
+	Synced() <-chan struct{}
+}
+
+type PollingPodCacheOption func(*pollingPodCache)
+
+func WithPollInterval(interval time.Duration) PollingPodCacheOption {
+	return func(c *pollingPodCache) {
+		c.interval = interval
+	}
+}
+
+// WithMinRefreshInterval sets how often lookups missing the cache may list
+// the pods out of turn.
+func WithMinRefreshInterval(interval time.Duration) PollingPodCacheOption {
+	return func(c *pollingPodCache) {
+		c.minRefreshInterval = interval
+	}
+}
+
+type pollingPodCache struct {
+	lister             PodLister
+	interval           time.Duration
+	minRefreshInterval time.Duration
+	polledAt           time.Time
+	pollMu             sync.Mutex
+
+	pods map[types.UID]corev1.Pod
+	// uids maps the config hash of static pods to their UID
+	uids     map[string]types.UID
+	synced   chan struct{}
+	syncOnce sync.Once
+	mu       sync.RWMutex
+}
+
+// NewPollingPodCache lists the pods with lister periodically until ctx is
+// done and applies the differences to the index. Useful with listers having
+// no watch support, like the kubelet. Pods started in between are picked up
+// by listing the pods again when a lookup misses the index.
+func NewPollingPodCache(ctx context.Context, lister PodLister, opts ...PollingPodCacheOption) PodCache {
+	c := &pollingPodCache{
+		lister:             lister,
+		interval:           defaultPollInterval,
+		minRefreshInterval: defaultMinRefreshInterval,
+		pods:               map[types.UID]corev1.Pod{},
+		uids:               map[string]types.UID{},
+		synced:             make(chan struct{}),
+	}
+
+	for _, f := range opts {
+		f(c)
+	}
+
+	go c.run(ctx)
+
+	return c
+}
+
+func (c *pollingPodCache) run(ctx context.Context) {
+	// the cache is not synced until the first poll succeeds, so it is not
+	// left waiting for the next tick
+	if _, err := backoff.Retry(ctx, func() (struct{}, error) {
+		return struct{}{}, c.poll(ctx)
+	}, backoff.WithMaxElapsedTime(0)); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_ = c.poll(ctx)
+	}
+}
+
+// refresh polls unless the last attempt is more recent than the minimum
+// refresh interval.
+func (c *pollingPodCache) refresh(ctx context.Context) error {
+	c.pollMu.Lock()
+	polledAt := c.polledAt
+	c.pollMu.Unlock()
+
+	if time.Since(polledAt) < c.minRefreshInterval {
+		return nil
+	}
+
+	return c.poll(ctx)
+}
+
+func (c *pollingPodCache) poll(ctx context.Context) error {
+	c.pollMu.Lock()
+	defer c.pollMu.Unlock()
+
+	c.polledAt = time.Now()
+
+	pods, err := c.lister.GetPods(ctx)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[types.UID]struct{}, len(pods))
+
+	c.mu.Lock()
+	clear(c.uids)
+	for _, pod := range pods {
+		seen[pod.GetUID()] = struct{}{}
+		c.pods[pod.GetUID()] = pod
+
+		for _, uid := range PodUIDs(pod)[1:] {
+			c.uids[uid] = pod.GetUID()
+		}
+	}
+
+	for uid := range c.pods {
+		if _, ok := seen[uid]; !ok {
+			delete(c.pods, uid)
+		}
+	}
+	c.mu.Unlock()
+
+	c.syncOnce.Do(func() {
+		close(c.synced)
+	})
+
+	return nil
+}
+
+func (c *pollingPodCache) GetPods(ctx context.Context) ([]corev1.Pod, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	pods := make([]corev1.Pod, 0, len(c.pods))
+	for _, pod := range c.pods {
+		pods = append(pods, pod)
+	}
+
+	return pods, nil
+}
+
+func (c *pollingPodCache) GetPod(uid string) (corev1.Pod, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if pod, ok := c.pods[types.UID(uid)]; ok {
+		return pod, true
+	}
+
+	pod, ok := c.pods[c.uids[uid]]
+
+	return pod, ok
+}
+
+func (c *pollingPodCache) Synced() <-chan struct{} {
+	return c.synced
+}
@@ -0,0 +1,79 @@
+package kubernetes
+
+import (
+	"context"
+	"strconv"
+
+	"emperror.dev/errors"
+	podresourcesv1 "k8s.io/kubelet/pkg/apis/podresources/v1"
+
+	"github.com/gezacorp/metadatax"
+)
+
+var PodResourcesNotFoundError = errors.Sentinel("could not find pod resources")
+
+// PodResourcesGetter returns the resources the kubelet allocated to the
+// containers of a pod, like the kubelet PodResources API does.
+// It returns PodResourcesNotFoundError for pods it does not know about.
+type PodResourcesGetter interface {
+	GetPodResources(ctx context.Context, namespace, name string) (*podresourcesv1.PodResources, error)
+}
+
+func (c *collector) allocatedResources(ctx context.Context, podctx podContext, md metadatax.MetadataContainer) error {
+	resources, err := c.podResourcesGetter.GetPodResources(ctx, podctx.pod.GetNamespace(), podctx.pod.GetName())
+	// the pod might not have reached the kubelet yet
+	if errors.Is(err, PodResourcesNotFoundError) {
+		return nil
+	}
+
+	if err != nil {
+		if c.skipOnSoftError {
+			return nil
+		}
+
+		return errors.WrapIfWithDetails(err, "could not get pod resources", "pod", podctx.pod.GetName())
+	}
+
+	for _, container := range resources.GetContainers() {
+		if container.GetName() == podctx.container.Name {
+			containerResources(container, md.Segment("container").Segment("resources").Segment("allocated"))
+		}
+	}
+
+	return nil
+}
+
+func containerResources(resources *podresourcesv1.ContainerResources, md metadatax.MetadataContainer) {
+	dmd := md.Segment("device")
+	for _, device := range resources.GetDevices() {
+		for _, id := range device.GetDeviceIds() {
+			dmd.AddLabel(device.GetResourceName(), id)
+		}
+	}
+
+	for _, id := range resources.GetCpuIds() {
+		md.AddLabel("cpu", strconv.FormatInt(id, 10))
+	}
+
+	mmd := md.Segment("memory")
+	for _, memory := range resources.GetMemory() {
+		tmd := mmd.Segment(memory.GetMemoryType())
+		tmd.AddLabel("size", strconv.FormatUint(memory.GetSize_(), 10))
+		for _, node := range memory.GetTopology().GetNodes() {
+			tmd.AddLabel("numa", strconv.FormatInt(node.GetID(), 10))
+		}
+	}
+
+	rmd := md.Segment("dynamic")
+	for _, resource := range resources.GetDynamicResources() {
+		cmd := rmd.Segment(resource.GetClaimName())
+		for _, claim := range resource.GetClaimResources() {
+			cmd.AddLabel("driver", claim.GetDriverName()).
+				AddLabel("pool", claim.GetPoolName()).
+				AddLabel("device", claim.GetDeviceName())
+			for _, cdi := range claim.GetCDIDevices() {
+				cmd.AddLabel("cdi-device", cdi.GetName())
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package kubernetes_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	podresourcesv1 "k8s.io/kubelet/pkg/apis/podresources/v1"
+
+	"github.com/gezacorp/metadatax"
+	"github.com/gezacorp/metadatax/collectors/kubernetes"
+	"github.com/gezacorp/metadatax/collectors/kubernetes/kubelet"
+)
+
+type fakePodResourcesServer struct {
+	podresourcesv1.UnimplementedPodResourcesListerServer
+
+	lists atomic.Int32
+}
+
+func (s *fakePodResourcesServer) List(ctx context.Context, req *podresourcesv1.ListPodResourcesRequest) (*podresourcesv1.ListPodResourcesResponse, error) {
+	s.lists.Add(1)
+
+	return &podresourcesv1.ListPodResourcesResponse{
+		PodResources: []*podresourcesv1.PodResources{
+			{
+				Name:      "schemareg-74f8949dc-qlbs4",
+				Namespace: "default",
+			},
+			{
+				Name:      "metrics-server-648b5df564-drsb2",
+				Namespace: "kube-system",
+				Containers: []*podresourcesv1.ContainerResources{
+					{
+						Name: "metrics-server",
+						Devices: []*podresourcesv1.ContainerDevices{
+							{
+								ResourceName: "nvidia.com/gpu",
+								DeviceIds:    []string{"GPU-8f6b1c2e", "GPU-3a9d7e41"},
+							},
+						},
+						CpuIds: []int64{2, 3},
+						Memory: []*podresourcesv1.ContainerMemory{
+							{
+								MemoryType: "memory",
+								Size_:      1073741824,
+								Topology: &podresourcesv1.TopologyInfo{
+									Nodes: []*podresourcesv1.NUMANode{{ID: 0}},
+								},
+							},
+						},
+						DynamicResources: []*podresourcesv1.DynamicResource{
+							{
+								ClaimName:      "gpu-claim",
+								ClaimNamespace: "kube-system",
+								ClaimResources: []*podresourcesv1.ClaimResource{
+									{
+										DriverName: "gpu.example.com",
+										PoolName:   "lima-k3s",
+										DeviceName: "gpu-0",
+										CDIDevices: []*podresourcesv1.CDIDevice{
+											{Name: "example.com/gpu=gpu-0"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func startFakePodResourcesServer(t *testing.T, srv *fakePodResourcesServer) string {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "kubelet.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	podresourcesv1.RegisterPodResourcesListerServer(server, srv)
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	return socketPath
+}
+
+func TestGetMetadataWithPodResourcesGetter(t *testing.T) {
+	t.Parallel()
+
+	srv := &fakePodResourcesServer{}
+	getter, err := kubelet.NewPodResourcesClient(kubelet.WithPodResourcesSocketPath("unix://" + startFakePodResourcesServer(t, srv)))
+	require.NoError(t, err)
+
+	collector := kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithPodResourcesGetter(getter),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	require.NoError(t, err)
+
+	expectedLabels := map[string][]string{
+		"kubernetes:container:resources:allocated:cpu":                          {"2", "3"},
+		"kubernetes:container:resources:allocated:device:nvidia.com/gpu":        {"GPU-8f6b1c2e", "GPU-3a9d7e41"},
+		"kubernetes:container:resources:allocated:dynamic:gpu-claim:cdi-device": {"example.com/gpu=gpu-0"},
+		"kubernetes:container:resources:allocated:dynamic:gpu-claim:device":     {"gpu-0"},
+		"kubernetes:container:resources:allocated:dynamic:gpu-claim:driver":     {"gpu.example.com"},
+		"kubernetes:container:resources:allocated:dynamic:gpu-claim:pool":       {"lima-k3s"},
+		"kubernetes:container:resources:allocated:memory:memory:numa":           {"0"},
+		"kubernetes:container:resources:allocated:memory:memory:size":           {"1073741824"},
+	}
+
+	allocated := map[string][]string{}
+	for k, v := range md.GetLabels() {
+		if strings.HasPrefix(k, "kubernetes:container:resources:allocated:") {
+			allocated[k] = v
+		}
+	}
+
+	assert.Equal(t, expectedLabels, allocated)
+
+	_, err = collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	require.NoError(t, err)
+	// the listing is reused
+	assert.Equal(t, int32(1), srv.lists.Load())
+
+	_, err = getter.GetPodResources(context.Background(), "default", "missing")
+	assert.ErrorIs(t, err, kubelet.PodResourcesNotFoundError)
+	// pods missing from the listing trigger a new one
+	assert.Equal(t, int32(2), srv.lists.Load())
+}
+
+func TestGetMetadataWithUnavailablePodResources(t *testing.T) {
+	t.Parallel()
+
+	getter, err := kubelet.NewPodResourcesClient(kubelet.WithPodResourcesSocketPath("unix://" + filepath.Join(t.TempDir(), "kubelet.sock")))
+	require.NoError(t, err)
+
+	collector := kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithPodResourcesGetter(getter),
+	)
+
+	_, err = collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.Error(t, err)
+
+	collector = kubernetes.New(
+		kubernetes.WithPodLister(&kubeletClient{}),
+		kubernetes.WithPodResolver(&podResolver{}),
+		kubernetes.WithPodResourcesGetter(getter),
+		kubernetes.WithSkipOnSoftError(),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metrics-server-648b5df564-drsb2"}, md.GetLabels()["kubernetes:pod:name"])
+}
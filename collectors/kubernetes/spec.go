@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gezacorp/metadatax"
+)
+
+func (c *collector) podSpec(podctx podContext, md metadatax.MetadataContainer) {
+	pod := podctx.pod
+	pmd := md.Segment("pod")
+
+	if len(pod.Status.PodIPs) > 0 {
+		for _, ip := range pod.Status.PodIPs {
+			pmd.AddLabel("ip", ip.IP)
+		}
+	} else {
+		pmd.AddLabel("ip", pod.Status.PodIP)
+	}
+
+	if len(pod.Status.HostIPs) > 0 {
+		for _, ip := range pod.Status.HostIPs {
+			pmd.AddLabel("host-ip", ip.IP)
+		}
+	} else {
+		pmd.AddLabel("host-ip", pod.Status.HostIP)
+	}
+
+	pmd.AddLabel("qos-class", string(pod.Status.QOSClass))
+	pmd.AddLabel("priority-class", pod.Spec.PriorityClassName)
+	if pod.Spec.RuntimeClassName != nil {
+		pmd.AddLabel("runtime-class", *pod.Spec.RuntimeClassName)
+	}
+
+	pmd.AddLabel("host-network", strconv.FormatBool(pod.Spec.HostNetwork))
+	pmd.AddLabel("host-pid", strconv.FormatBool(pod.Spec.HostPID))
+	pmd.AddLabel("host-ipc", strconv.FormatBool(pod.Spec.HostIPC))
+	if pod.Spec.AutomountServiceAccountToken != nil {
+		pmd.AddLabel("automount-service-account-token", strconv.FormatBool(*pod.Spec.AutomountServiceAccountToken))
+	}
+
+	if sc := pod.Spec.SecurityContext; sc != nil {
+		smd := pmd.Segment("security")
+		addInt64Label(smd, "run-as-user", sc.RunAsUser)
+		addInt64Label(smd, "run-as-group", sc.RunAsGroup)
+		addBoolLabel(smd, "run-as-non-root", sc.RunAsNonRoot)
+		addInt64Label(smd, "fs-group", sc.FSGroup)
+		seccompProfile(sc.SeccompProfile, smd)
+	}
+}
+
+func (c *collector) containerSpec(podctx podContext, md metadatax.MetadataContainer) {
+	container := podctx.container
+	cmd := md.Segment("container")
+
+	rmd := cmd.Segment("resources")
+	for resource, quantity := range container.Resources.Requests {
+		rmd.Segment("requests").AddLabel(string(resource), quantity.String())
+	}
+	for resource, quantity := range container.Resources.Limits {
+		rmd.Segment("limits").AddLabel(string(resource), quantity.String())
+	}
+
+	for _, port := range container.Ports {
+		cmd.AddLabel("port", strconv.Itoa(int(port.ContainerPort))+"/"+strings.ToLower(string(port.Protocol)))
+	}
+
+	if podctx.containerStatus.Name != "" {
+		cmd.AddLabel("restart-count", strconv.Itoa(int(podctx.containerStatus.RestartCount)))
+	}
+
+	if sc := container.SecurityContext; sc != nil {
+		smd := cmd.Segment("security")
+		addInt64Label(smd, "run-as-user", sc.RunAsUser)
+		addInt64Label(smd, "run-as-group", sc.RunAsGroup)
+		addBoolLabel(smd, "run-as-non-root", sc.RunAsNonRoot)
+		addBoolLabel(smd, "privileged", sc.Privileged)
+		addBoolLabel(smd, "allow-privilege-escalation", sc.AllowPrivilegeEscalation)
+		addBoolLabel(smd, "read-only-root-filesystem", sc.ReadOnlyRootFilesystem)
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				smd.AddLabel("cap-add", string(capability))
+			}
+			for _, capability := range sc.Capabilities.Drop {
+				smd.AddLabel("cap-drop", string(capability))
+			}
+		}
+		seccompProfile(sc.SeccompProfile, smd)
+	}
+}
+
+func seccompProfile(profile *corev1.SeccompProfile, md metadatax.MetadataContainer) {
+	if profile == nil {
+		return
+	}
+
+	md.AddLabel("seccomp-profile", string(profile.Type))
+	if profile.LocalhostProfile != nil {
+		md.AddLabel("seccomp-localhost-profile", *profile.LocalhostProfile)
+	}
+}
+
+func addInt64Label(md metadatax.MetadataContainer, key string, value *int64) {
+	if value != nil {
+		md.AddLabel(key, strconv.FormatInt(*value, 10))
+	}
+}
+
+func addBoolLabel(md metadatax.MetadataContainer, key string, value *bool) {
+	if value != nil {
+		md.AddLabel(key, strconv.FormatBool(*value))
+	}
+}
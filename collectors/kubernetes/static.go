@@ -0,0 +1,47 @@
+package kubernetes
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	ConfigHashAnnotation   = "kubernetes.io/config.hash"
+	ConfigMirrorAnnotation = "kubernetes.io/config.mirror"
+	ConfigSourceAnnotation = "kubernetes.io/config.source"
+
+	apiConfigSource = "api"
+)
+
+// IsStaticPod reports whether the pod was started by the kubelet from a
+// manifest, either as seen by the kubelet or as its mirror pod in the
+// apiserver.
+func IsStaticPod(pod corev1.Pod) bool {
+	if _, ok := pod.GetAnnotations()[ConfigMirrorAnnotation]; ok {
+		return true
+	}
+
+	source, ok := pod.GetAnnotations()[ConfigSourceAnnotation]
+
+	return ok && source != apiConfigSource
+}
+
+// PodUIDs returns the UIDs the pod is known by. Static pods run with the
+// config hash as their UID on the node, which differs from the UID of their
+// mirror pod in the apiserver.
+func PodUIDs(pod corev1.Pod) []string {
+	uids := []string{string(pod.GetUID())}
+
+	if !IsStaticPod(pod) {
+		return uids
+	}
+
+	for _, key := range []string{ConfigMirrorAnnotation, ConfigHashAnnotation} {
+		if hash := pod.GetAnnotations()[key]; hash != "" && !slices.Contains(uids, hash) {
+			uids = append(uids, hash)
+		}
+	}
+
+	return uids
+}
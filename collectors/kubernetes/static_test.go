@@ -0,0 +1,77 @@
+package kubernetes_test
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gezacorp/metadatax"
+	"github.com/gezacorp/metadatax/collectors/kubernetes"
+)
+
+//go:embed testdata/mirror-pod.json
+var testMirrorPodJSON []byte
+
+type mirrorPodLister struct{}
+
+func (l *mirrorPodLister) GetPods(ctx context.Context) ([]corev1.Pod, error) {
+	var pod corev1.Pod
+	if err := json.Unmarshal(testMirrorPodJSON, &pod); err != nil {
+		return nil, err
+	}
+
+	return []corev1.Pod{pod}, nil
+}
+
+// TestGetMetadataForStaticPod resolves the config hash of the static pod from
+// the cgroup path of the process, like the kubelet names the pod slice.
+func TestGetMetadataForStaticPod(t *testing.T) {
+	t.Setenv("HOST_PROC", "testdata/proc")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	for name, lister := range map[string]kubernetes.PodLister{
+		"lister": &mirrorPodLister{},
+		"cache":  kubernetes.NewPollingPodCache(ctx, &mirrorPodLister{}, kubernetes.WithPollInterval(time.Hour)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			collector := kubernetes.New(
+				kubernetes.WithPodLister(lister),
+			)
+
+			md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 4242))
+			require.NoError(t, err)
+
+			labels := md.GetLabels()
+			assert.Equal(t, []string{"etcd-control-plane"}, labels["kubernetes:pod:name"])
+			assert.Equal(t, []string{"true"}, labels["kubernetes:pod:static"])
+			assert.Equal(t, []string{"etcd"}, labels["kubernetes:container:name"])
+		})
+	}
+}
+
+func TestPodUIDs(t *testing.T) {
+	t.Parallel()
+
+	pods, err := (&mirrorPodLister{}).GetPods(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, kubernetes.IsStaticPod(pods[0]))
+	assert.Equal(t, []string{
+		"0f3a8c3e-7b1d-4c55-9a7e-2b6f1d9e4c21",
+		"8d4e1a3b7c2f9e6d5a0b1c2d3e4f5a6b",
+	}, kubernetes.PodUIDs(pods[0]))
+
+	pods, err = (&kubeletClient{}).GetPods(context.Background())
+	require.NoError(t, err)
+
+	assert.False(t, kubernetes.IsStaticPod(pods[0]))
+	assert.Equal(t, []string{"5831c41b-55ba-4e82-9c6e-2d3ad9d8bfe9"}, kubernetes.PodUIDs(pods[0]))
+}
@@ -4,12 +4,13 @@ import (
 	"bytes"
 	"crypto/tls"
 	"os"
-	"regexp"
 	"sync"
 	"time"
 
 	"emperror.dev/errors"
 	"github.com/prometheus/procfs"
+
+	cgroupparser "github.com/gezacorp/metadatax/cgroups"
 )
 
 type Cgroup = procfs.Cgroup
@@ -43,15 +44,18 @@ func GetCgroupsForPID(pid int) ([]Cgroup, error) {
 }
 
 func GetContainerIDFromCgroups(cgroups []Cgroup) string {
-	dockerCgroupRegex := regexp.MustCompile(`\b((?i)[a-z0-9]{64})`)
+	info, _ := cgroupparser.Parse(cgroupPaths(cgroups))
+
+	return info.ContainerID
+}
 
+func cgroupPaths(cgroups []Cgroup) []string {
+	paths := make([]string, 0, len(cgroups))
 	for _, cgroup := range cgroups {
-		if match := dockerCgroupRegex.FindStringSubmatch(cgroup.Path); len(match) > 0 {
-			return match[1]
-		}
+		paths = append(paths, cgroup.Path)
 	}
 
-	return ""
+	return paths
 }
 
 func NodeName() (string, error) {
@@ -0,0 +1,141 @@
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"emperror.dev/errors"
+
+	"github.com/gezacorp/metadatax"
+)
+
+const (
+	apiVersion = "v4.0.0"
+)
+
+var NotFoundError = errors.Sentinel("not found")
+
+// ContainerInspectResponse holds the fields of the libpod container inspect
+// response used by the collector.
+type ContainerInspectResponse struct {
+	ID          string `json:"Id"`
+	Name        string
+	Path        string
+	Args        []string
+	Image       string
+	ImageName   string
+	ImageDigest string
+	Pod         string
+	IsInfra     bool
+	Config      ContainerConfig
+	HostConfig  ContainerHostConfig
+}
+
+type ContainerConfig struct {
+	Hostname string
+	Env      []string
+	Labels   map[string]string
+}
+
+type ContainerHostConfig struct {
+	NetworkMode  string
+	UsernsMode   string
+	PortBindings map[string]any
+}
+
+type PodInspectResponse struct {
+	ID               string `json:"Id"`
+	Name             string
+	InfraContainerID string
+	Labels           map[string]string
+}
+
+type Info struct {
+	Host struct {
+		Security struct {
+			Rootless bool
+		}
+	}
+}
+
+type libpodClient struct {
+	httpClient metadatax.HTTPClient
+}
+
+// NewClient returns a ContainerInspector using the libpod REST API served on
+// the unix socket at socketPath.
+func NewClient(socketPath string) ContainerInspector {
+	socketPath = strings.TrimPrefix(socketPath, "unix://")
+
+	return &libpodClient{
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+
+					return d.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+func (c *libpodClient) ContainerInspect(ctx context.Context, containerID string) (ContainerInspectResponse, error) {
+	var resp ContainerInspectResponse
+	if err := c.get(ctx, "/libpod/containers/"+url.PathEscape(containerID)+"/json", &resp); err != nil {
+		return resp, errors.WrapIfWithDetails(err, "could not inspect container", "containerID", containerID)
+	}
+
+	return resp, nil
+}
+
+func (c *libpodClient) PodInspect(ctx context.Context, podID string) (PodInspectResponse, error) {
+	var resp PodInspectResponse
+	if err := c.get(ctx, "/libpod/pods/"+url.PathEscape(podID)+"/json", &resp); err != nil {
+		return resp, errors.WrapIfWithDetails(err, "could not inspect pod", "podID", podID)
+	}
+
+	return resp, nil
+}
+
+func (c *libpodClient) Info(ctx context.Context) (Info, error) {
+	var resp Info
+	if err := c.get(ctx, "/libpod/info", &resp); err != nil {
+		return resp, errors.WrapIf(err, "could not get info")
+	}
+
+	return resp, nil
+}
+
+func (c *libpodClient) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://d/"+apiVersion+path, nil)
+	if err != nil {
+		return errors.WrapIf(err, "could not instantiate http request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.WrapIf(err, "could not perform http request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return NotFoundError
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("non-200 response status: %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.WrapIf(err, "could not read response")
+	}
+
+	return errors.WithStackIf(json.Unmarshal(content, v))
+}
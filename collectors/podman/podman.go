@@ -0,0 +1,294 @@
+package podman
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+
+	"github.com/gezacorp/metadatax"
+)
+
+const (
+	name = "podman"
+
+	rootfulSocketPath = "unix:///run/podman/podman.sock"
+)
+
+var ContainerIDNotFoundError = errors.Sentinel("could not find container id for pid")
+
+type collector struct {
+	socketPath         string
+	containerInspector ContainerInspector
+	containerIDGetter  ContainerIDGetter
+
+	mdContainerInitFunc func() metadatax.MetadataContainer
+	skipOnSoftError     bool
+	hasPodman           *bool
+
+	rootless *bool
+	mu       sync.Mutex
+}
+
+type ContainerInspector interface {
+	ContainerInspect(ctx context.Context, containerID string) (ContainerInspectResponse, error)
+	PodInspect(ctx context.Context, podID string) (PodInspectResponse, error)
+	Info(ctx context.Context) (Info, error)
+}
+
+type ContainerIDGetter interface {
+	GetContainerIDFromPID(pid int) (string, error)
+}
+
+type CollectorOption func(*collector)
+
+func WithSocketPath(socketPath string) CollectorOption {
+	return func(c *collector) {
+		c.socketPath = socketPath
+	}
+}
+
+func WithContainerInspector(inspector ContainerInspector) CollectorOption {
+	return func(c *collector) {
+		c.containerInspector = inspector
+	}
+}
+
+func WithContainerIDGetter(containerIDGetter ContainerIDGetter) CollectorOption {
+	return func(c *collector) {
+		c.containerIDGetter = containerIDGetter
+	}
+}
+
+func CollectorWithMetadataContainerInitFunc(fn func() metadatax.MetadataContainer) CollectorOption {
+	return func(c *collector) {
+		c.mdContainerInitFunc = fn
+	}
+}
+
+func WithSkipOnSoftError() CollectorOption {
+	return func(c *collector) {
+		c.skipOnSoftError = true
+	}
+}
+
+func New(opts ...CollectorOption) metadatax.Collector {
+	c := &collector{}
+
+	for _, f := range opts {
+		f(c)
+	}
+
+	if c.socketPath == "" {
+		if host := os.Getenv("CONTAINER_HOST"); strings.HasPrefix(host, "unix://") {
+			c.socketPath = host
+		}
+	}
+
+	if c.socketPath == "" {
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && c.isSocketPathExists(filepath.Join(dir, "podman", "podman.sock")) {
+			c.socketPath = "unix://" + filepath.Join(dir, "podman", "podman.sock")
+		}
+	}
+
+	if c.socketPath == "" {
+		c.socketPath = rootfulSocketPath
+	}
+
+	if c.containerIDGetter == nil {
+		c.containerIDGetter = c
+	}
+
+	if c.mdContainerInitFunc == nil {
+		c.mdContainerInitFunc = func() metadatax.MetadataContainer {
+			return metadatax.New(metadatax.WithPrefix(name))
+		}
+	}
+
+	return c
+}
+
+func (c *collector) HasPodman() bool {
+	if c.hasPodman != nil {
+		return *c.hasPodman
+	}
+
+	ret := c.isSocketPathExists(c.socketPath)
+	c.hasPodman = &ret
+
+	return ret
+}
+
+func (c *collector) isSocketPathExists(path string) bool {
+	path = strings.TrimPrefix(path, "unix://")
+
+	if _, err := os.Open(path); errors.Is(err, os.ErrPermission) {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return (info.Mode() & os.ModeSocket) != 0
+}
+
+func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContainer, error) {
+	md := c.mdContainerInitFunc()
+
+	if c.containerInspector == nil {
+		if !c.HasPodman() {
+			return md, nil
+		}
+
+		c.containerInspector = NewClient(c.socketPath)
+	}
+
+	pid, found := metadatax.PIDFromContext(ctx)
+	if !found {
+		return nil, metadatax.PIDNotFoundError
+	}
+
+	containerID, err := c.containerIDGetter.GetContainerIDFromPID(int(pid))
+	if err != nil {
+		if c.skipOnSoftError {
+			return md, nil
+		}
+
+		return nil, errors.WrapIfWithDetails(err, "could not get cgroups from pid", "pid", pid)
+	}
+
+	if containerID == "" {
+		if c.skipOnSoftError {
+			return md, nil
+		}
+
+		return nil, errors.WithDetails(ContainerIDNotFoundError, "pid", pid)
+	}
+
+	containerJSON, err := c.containerInspector.ContainerInspect(ctx, containerID)
+	if c.skipOnSoftError && errors.Is(err, NotFoundError) {
+		return md, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rootless, err := c.isRootless(ctx)
+	if err != nil && !c.skipOnSoftError {
+		return nil, err
+	}
+	if err == nil {
+		md.AddLabel("rootless", strconv.FormatBool(rootless))
+	}
+
+	if containerJSON.Pod != "" {
+		podJSON, err := c.containerInspector.PodInspect(ctx, containerJSON.Pod)
+		if err != nil && !errors.Is(err, NotFoundError) && !c.skipOnSoftError {
+			return nil, err
+		}
+
+		c.pod(containerJSON, podJSON, md)
+	}
+
+	getters := []func(ContainerInspectResponse, metadatax.MetadataContainer){
+		c.base,
+		c.labels,
+		c.envs,
+		c.image,
+		c.network,
+	}
+
+	for _, f := range getters {
+		f(containerJSON, md)
+	}
+
+	return md, nil
+}
+
+func (c *collector) isRootless(ctx context.Context) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rootless != nil {
+		return *c.rootless, nil
+	}
+
+	info, err := c.containerInspector.Info(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	c.rootless = &info.Host.Security.Rootless
+
+	return *c.rootless, nil
+}
+
+func (c *collector) GetContainerIDFromPID(pid int) (string, error) {
+	cgroups, err := GetCgroupsForPID(pid)
+	if err != nil {
+		return "", errors.WithStackIf(err)
+	}
+
+	return GetContainerIDFromCgroups(cgroups), nil
+}
+
+func (c *collector) base(containerJSON ContainerInspectResponse, md metadatax.MetadataContainer) {
+	md.AddLabel("id", containerJSON.ID)
+	md.AddLabel("name", containerJSON.Name)
+	md.AddLabel("cmdline", containerJSON.Path+" "+strings.Join(containerJSON.Args, " "))
+	md.AddLabel("infra", strconv.FormatBool(containerJSON.IsInfra))
+	md.AddLabel("userns-mode", containerJSON.HostConfig.UsernsMode)
+}
+
+func (c *collector) pod(containerJSON ContainerInspectResponse, podJSON PodInspectResponse, md metadatax.MetadataContainer) {
+	pmd := md.Segment("pod")
+	pmd.AddLabel("id", containerJSON.Pod)
+	pmd.AddLabel("name", podJSON.Name)
+	pmd.AddLabel("infra-container-id", podJSON.InfraContainerID)
+
+	lmd := pmd.Segment("label")
+	for k, v := range podJSON.Labels {
+		lmd.AddLabel(k, v)
+	}
+}
+
+func (c *collector) envs(containerJSON ContainerInspectResponse, md metadatax.MetadataContainer) {
+	emd := md.Segment("env")
+	for _, env := range containerJSON.Config.Env {
+		if !strings.Contains(env, "=") {
+			continue
+		}
+		p := strings.SplitN(env, "=", 2)
+		emd.AddLabel(strings.ToUpper(p[0]), p[1])
+	}
+}
+
+func (c *collector) labels(containerJSON ContainerInspectResponse, md metadatax.MetadataContainer) {
+	lmd := md.Segment("label")
+	for k, v := range containerJSON.Config.Labels {
+		lmd.AddLabel(k, v)
+	}
+}
+
+func (c *collector) image(containerJSON ContainerInspectResponse, md metadatax.MetadataContainer) {
+	md.Segment("image").
+		AddLabel("name", containerJSON.ImageName).
+		AddLabel("hash", containerJSON.Image).
+		AddLabel("digest", containerJSON.ImageDigest)
+}
+
+func (c *collector) network(containerJSON ContainerInspectResponse, md metadatax.MetadataContainer) {
+	nmd := md.Segment("network")
+	nmd.AddLabel("mode", containerJSON.HostConfig.NetworkMode)
+	nmd.AddLabel("hostname", containerJSON.Config.Hostname)
+	for port := range containerJSON.HostConfig.PortBindings {
+		md.AddLabel("port-binding", port)
+	}
+}
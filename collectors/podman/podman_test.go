@@ -0,0 +1,176 @@
+package podman_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gezacorp/metadatax"
+	"github.com/gezacorp/metadatax/collectors/podman"
+)
+
+const (
+	containerID = "9b1d0c6a0f8e4b7d3c2a1f0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d0c"
+	podID       = "3e2b8f1c9d0a7e6f5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f2a"
+)
+
+type containerIDGetter struct {
+	id string
+}
+
+func (g *containerIDGetter) GetContainerIDFromPID(pid int) (string, error) {
+	return g.id, nil
+}
+
+func startFakeServer(t *testing.T) string {
+	t.Helper()
+
+	return startFakeServerWithFailingLookups(t, false)
+}
+
+// startFakeServerWithFailingLookups serves the container, but fails the pod
+// and info lookups when failing is set.
+func startFakeServerWithFailingLookups(t *testing.T, failing bool) string {
+	t.Helper()
+
+	serveFile := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, filepath.Join("testdata", name))
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v4.0.0/libpod/containers/"+containerID+"/json", serveFile("container.json"))
+	if failing {
+		mux.HandleFunc("GET /v4.0.0/libpod/pods/"+podID+"/json", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"cause":"database is locked","message":"database is locked","response":500}`, http.StatusInternalServerError)
+		})
+		mux.HandleFunc("GET /v4.0.0/libpod/info", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"cause":"database is locked","message":"database is locked","response":500}`, http.StatusInternalServerError)
+		})
+	} else {
+		mux.HandleFunc("GET /v4.0.0/libpod/pods/"+podID+"/json", serveFile("pod.json"))
+		mux.HandleFunc("GET /v4.0.0/libpod/info", serveFile("info.json"))
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "podman.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+
+	return socketPath
+}
+
+func TestGetMetadata(t *testing.T) {
+	t.Parallel()
+
+	collector := podman.New(
+		podman.WithSocketPath("unix://"+startFakeServer(t)),
+		podman.WithContainerIDGetter(&containerIDGetter{id: containerID}),
+	)
+
+	expectedLabels := map[string][]string{
+		"podman:cmdline":                {"/docker-entrypoint.sh nginx -g daemon off;"},
+		"podman:env:CONTAINER":          {"podman"},
+		"podman:env:HOSTNAME":           {"web"},
+		"podman:env:NGINX_VERSION":      {"1.27.5"},
+		"podman:env:PATH":               {"/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"},
+		"podman:id":                     {containerID},
+		"podman:image:digest":           {"sha256:a484819eb60211f5299034ac80f6a681b06f89e65866ce91f356ed7c72af059c"},
+		"podman:image:hash":             {"a8758716bb6aa4d90071160d27028fe4eaee7ce8166221a97d30440c8eac2be6"},
+		"podman:image:name":             {"docker.io/library/nginx:1.27"},
+		"podman:infra":                  {"false"},
+		"podman:label:maintainer":       {"NGINX Docker Maintainers <docker-maint@nginx.com>"},
+		"podman:name":                   {"web-nginx"},
+		"podman:network:hostname":       {"web"},
+		"podman:network:mode":           {"container:5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d"},
+		"podman:pod:id":                 {podID},
+		"podman:pod:infra-container-id": {"5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d"},
+		"podman:pod:label:app":          {"web"},
+		"podman:pod:name":               {"web"},
+		"podman:rootless":               {"true"},
+		"podman:userns-mode":            {"keep-id"},
+	}
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 48213))
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedLabels, map[string][]string(md.GetLabels()))
+}
+
+func TestGetMetadataNotFound(t *testing.T) {
+	t.Parallel()
+
+	socketPath := startFakeServer(t)
+
+	collector := podman.New(
+		podman.WithSocketPath(socketPath),
+		podman.WithContainerIDGetter(&containerIDGetter{id: "missing"}),
+	)
+
+	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	assert.ErrorIs(t, err, podman.NotFoundError)
+
+	collector = podman.New(
+		podman.WithSocketPath(socketPath),
+		podman.WithContainerIDGetter(&containerIDGetter{id: "missing"}),
+		podman.WithSkipOnSoftError(),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 1))
+	require.NoError(t, err)
+	assert.Empty(t, md.GetLabels())
+}
+
+func TestGetMetadataSoftErrors(t *testing.T) {
+	t.Parallel()
+
+	socketPath := startFakeServerWithFailingLookups(t, true)
+
+	collector := podman.New(
+		podman.WithSocketPath(socketPath),
+		podman.WithContainerIDGetter(&containerIDGetter{id: containerID}),
+	)
+
+	_, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 48213))
+	assert.Error(t, err)
+
+	// the pod and the rootless flag are left out
+	collector = podman.New(
+		podman.WithSocketPath(socketPath),
+		podman.WithContainerIDGetter(&containerIDGetter{id: containerID}),
+		podman.WithSkipOnSoftError(),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 48213))
+	require.NoError(t, err)
+
+	labels := md.GetLabels()
+	assert.Equal(t, []string{"web-nginx"}, labels["podman:name"])
+	assert.Equal(t, []string{podID}, labels["podman:pod:id"])
+	assert.NotContains(t, labels, "podman:pod:name")
+	assert.NotContains(t, labels, "podman:rootless")
+}
+
+func TestGetContainerIDFromCgroups(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, containerID, podman.GetContainerIDFromCgroups([]podman.Cgroup{
+		{HierarchyID: 0, Path: "/user.slice/user-1000.slice/user@1000.service/user.slice/libpod-" + containerID + ".scope/container"},
+	}))
+	assert.Empty(t, podman.GetContainerIDFromCgroups([]podman.Cgroup{
+		{HierarchyID: 0, Path: "/system.slice/docker-" + containerID + ".scope"},
+	}))
+}
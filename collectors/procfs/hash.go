@@ -0,0 +1,82 @@
+package procfs
+
+import (
+	_ "crypto/sha256"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const defaultHashCacheSize = 4096
+
+type fileHashEntry struct {
+	size    int64
+	modTime time.Time
+	digest  digest.Digest
+}
+
+// fileHashCache keeps file digests keyed by the identity of the file, as the
+// same file is reached through the root of every process using it, and
+// revalidates them using its size and modification time.
+type fileHashCache struct {
+	entries    map[string]fileHashEntry
+	maxEntries int
+
+	mu sync.Mutex
+}
+
+func newFileHashCache(maxEntries int) *fileHashCache {
+	return &fileHashCache{
+		entries:    map[string]fileHashEntry{},
+		maxEntries: maxEntries,
+	}
+}
+
+func (c *fileHashCache) hashFile(file *os.File) (digest.Digest, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	key := fileIdentity(file.Name(), info)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+		return entry.digest, nil
+	}
+
+	hash, err := digest.SHA256.FromReader(file)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.maxEntries {
+		clear(c.entries)
+	}
+
+	c.entries[key] = fileHashEntry{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		digest:  hash,
+	}
+
+	return hash, nil
+}
+
+func (c *fileHashCache) hash(path string) (digest.Digest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return c.hashFile(file)
+}
@@ -0,0 +1,148 @@
+package procfs
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v4/net"
+
+	"github.com/gezacorp/metadatax"
+)
+
+const (
+	defaultNetworkPeersLimit = 32
+
+	connStatusListen      = "LISTEN"
+	connStatusEstablished = "ESTABLISHED"
+)
+
+type socketInventory struct {
+	protocols    []string
+	listen       map[string]int
+	established  map[string]int
+	bindings     []string
+	udpBindings  map[string][]string
+	unixPaths    []string
+	unixAbstract []string
+	peers        []string
+}
+
+func (c *collector) network(ctx context.Context, processInfo ProcessInfo, md metadatax.MetadataContainer) {
+	netmd := md.Segment("network")
+
+	conns, err := processInfo.ConnectionsWithContext(ctx)
+	if err != nil {
+		return
+	}
+
+	inv := c.getSocketInventory(conns)
+
+	netmd.AddLabel("binding", inv.bindings...)
+	netmd.AddLabel("protocol", inv.protocols...)
+
+	for _, proto := range inv.protocols {
+		pmd := netmd.Segment(proto)
+		switch proto {
+		case "tcp", "tcp6":
+			pmd.AddLabel("listen:count", strconv.Itoa(inv.listen[proto]))
+			pmd.AddLabel("established:count", strconv.Itoa(inv.established[proto]))
+		case "udp", "udp6":
+			pmd.AddLabel("binding", inv.udpBindings[proto]...)
+		case "unix":
+			pmd.AddLabel("path", inv.unixPaths...)
+			pmd.AddLabel("abstract", inv.unixAbstract...)
+		}
+	}
+
+	if c.networkPeersLimit > 0 {
+		netmd.AddLabel("peer", inv.peers...)
+	}
+}
+
+func (c *collector) getSocketInventory(conns []net.ConnectionStat) socketInventory {
+	inv := socketInventory{
+		listen:      map[string]int{},
+		established: map[string]int{},
+		udpBindings: map[string][]string{},
+	}
+
+	for _, conn := range conns {
+		if conn.Status == connStatusListen {
+			inv.bindings = appendUnique(inv.bindings, hostPort(conn.Laddr))
+		}
+
+		proto := connectionProtocol(conn)
+		if proto == "" {
+			continue
+		}
+
+		inv.protocols = appendUnique(inv.protocols, proto)
+
+		switch proto {
+		case "tcp", "tcp6":
+			switch conn.Status {
+			case connStatusListen:
+				inv.listen[proto]++
+			case connStatusEstablished:
+				inv.established[proto]++
+				if conn.Raddr.IP != "" && len(inv.peers) < c.networkPeersLimit {
+					inv.peers = appendUnique(inv.peers, hostPort(conn.Raddr))
+				}
+			}
+		case "udp", "udp6":
+			if conn.Laddr.Port != 0 {
+				inv.udpBindings[proto] = appendUnique(inv.udpBindings[proto], hostPort(conn.Laddr))
+			}
+		case "unix":
+			switch path := conn.Laddr.IP; {
+			case path == "":
+			case strings.HasPrefix(path, "@"):
+				inv.unixAbstract = appendUnique(inv.unixAbstract, path)
+			default:
+				inv.unixPaths = appendUnique(inv.unixPaths, path)
+			}
+		}
+	}
+
+	slices.Sort(inv.protocols)
+
+	return inv
+}
+
+func connectionProtocol(conn net.ConnectionStat) string {
+	switch conn.Family {
+	case syscall.AF_INET:
+		switch conn.Type {
+		case syscall.SOCK_STREAM:
+			return "tcp"
+		case syscall.SOCK_DGRAM:
+			return "udp"
+		}
+	case syscall.AF_INET6:
+		switch conn.Type {
+		case syscall.SOCK_STREAM:
+			return "tcp6"
+		case syscall.SOCK_DGRAM:
+			return "udp6"
+		}
+	case syscall.AF_UNIX:
+		return "unix"
+	}
+
+	return ""
+}
+
+func hostPort(addr net.Addr) string {
+	return addr.IP + ":" + strconv.Itoa(int(addr.Port))
+}
+
+func appendUnique(list []string, value string) []string {
+	if slices.Contains(list, value) {
+		return list
+	}
+
+	return append(list, value)
+}
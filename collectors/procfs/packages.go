@@ -0,0 +1,482 @@
+package procfs
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"emperror.dev/errors"
+	rpmdb "github.com/knqyf263/go-rpmdb/pkg"
+)
+
+const (
+	PackageManagerDpkg = "dpkg"
+	PackageManagerRPM  = "rpm"
+	PackageManagerAPK  = "apk"
+
+	defaultPackageCacheSize = 4096
+)
+
+var PackageNotFoundError = errors.Sentinel("could not find owning package")
+
+var (
+	dpkgStatusFile   = "/var/lib/dpkg/status"
+	dpkgStatusDir    = "/var/lib/dpkg/status.d"
+	dpkgInfoDir      = "/var/lib/dpkg/info"
+	apkInstalledFile = "/lib/apk/db/installed"
+	rpmDatabaseFiles = []string{
+		"/var/lib/rpm/rpmdb.sqlite",
+		"/var/lib/rpm/Packages.db",
+		"/var/lib/rpm/Packages",
+		"/usr/lib/sysimage/rpm/rpmdb.sqlite",
+		"/usr/lib/sysimage/rpm/Packages.db",
+		"/usr/lib/sysimage/rpm/Packages",
+	}
+)
+
+type Package struct {
+	Name    string
+	Version string
+	Manager string
+}
+
+type PackageResolver interface {
+	// GetPackageForPath returns the package owning path within the file
+	// system tree rooted at root.
+	GetPackageForPath(root string, path string) (*Package, error)
+}
+
+type packageDatabase interface {
+	manager() string
+	databaseFile(root string) (string, bool)
+	lookup(root string, paths []string) (*Package, error)
+}
+
+type packageCacheEntry struct {
+	pkg     *Package
+	modTime time.Time
+}
+
+type packageResolver struct {
+	databases  []packageDatabase
+	cache      map[string]packageCacheEntry
+	maxEntries int
+
+	mu sync.Mutex
+}
+
+// NewPackageResolver returns a resolver reading the dpkg, rpm and apk
+// databases found in a file system tree. Results are cached until the
+// database changes.
+func NewPackageResolver() PackageResolver {
+	return &packageResolver{
+		databases: []packageDatabase{
+			dpkgDatabase{},
+			apkDatabase{},
+			rpmDatabase{},
+		},
+		cache:      map[string]packageCacheEntry{},
+		maxEntries: defaultPackageCacheSize,
+	}
+}
+
+func (r *packageResolver) GetPackageForPath(root string, p string) (*Package, error) {
+	for _, db := range r.databases {
+		dbFile, ok := db.databaseFile(root)
+		if !ok {
+			continue
+		}
+
+		info, err := os.Stat(dbFile)
+		if err != nil {
+			continue
+		}
+
+		// the same database is reached through the root of every process
+		// of a container, so it is keyed by identity instead of path
+		key := fileIdentity(dbFile, info) + "\x00" + db.manager() + "\x00" + p
+		r.mu.Lock()
+		entry, ok := r.cache[key]
+		r.mu.Unlock()
+		if ok && entry.modTime.Equal(info.ModTime()) {
+			if entry.pkg == nil {
+				continue
+			}
+
+			return entry.pkg, nil
+		}
+
+		pkg, err := db.lookup(root, packagePathCandidates(p))
+		if err != nil && !errors.Is(err, PackageNotFoundError) {
+			return nil, errors.WrapIfWithDetails(err, "could not read package database", "manager", db.manager())
+		}
+
+		r.mu.Lock()
+		if len(r.cache) >= r.maxEntries {
+			clear(r.cache)
+		}
+		r.cache[key] = packageCacheEntry{
+			pkg:     pkg,
+			modTime: info.ModTime(),
+		}
+		r.mu.Unlock()
+
+		if pkg != nil {
+			return pkg, nil
+		}
+	}
+
+	return nil, errors.WithDetails(PackageNotFoundError, "path", p)
+}
+
+// fileIdentity returns the device and inode of a file, or its path if those
+// are not available.
+func fileIdentity(p string, info os.FileInfo) string {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return strconv.FormatUint(uint64(st.Dev), 10) + ":" + strconv.FormatUint(st.Ino, 10)
+	}
+
+	return p
+}
+
+// packagePathCandidates returns the path and its alias on merged /usr
+// systems, where packages may still list /bin, /sbin and /lib paths.
+func packagePathCandidates(p string) []string {
+	p = path.Clean(p)
+	paths := []string{p}
+
+	for _, dir := range []string{"/bin/", "/sbin/", "/lib/", "/lib64/"} {
+		if strings.HasPrefix(p, dir) {
+			return append(paths, "/usr"+p)
+		}
+		if strings.HasPrefix(p, "/usr"+dir) {
+			return append(paths, strings.TrimPrefix(p, "/usr"))
+		}
+	}
+
+	return paths
+}
+
+type dpkgDatabase struct{}
+
+func (dpkgDatabase) manager() string {
+	return PackageManagerDpkg
+}
+
+func (dpkgDatabase) databaseFile(root string) (string, bool) {
+	for _, p := range []string{dpkgStatusFile, dpkgStatusDir} {
+		if _, err := os.Stat(filepath.Join(root, p)); err == nil {
+			return filepath.Join(root, p), true
+		}
+	}
+
+	return "", false
+}
+
+func (db dpkgDatabase) lookup(root string, paths []string) (*Package, error) {
+	name, err := db.findOwner(root, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []string{filepath.Join(root, dpkgStatusFile)}
+	if entries, err := os.ReadDir(filepath.Join(root, dpkgStatusDir)); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() && !strings.HasSuffix(e.Name(), ".md5sums") {
+				statuses = append(statuses, filepath.Join(root, dpkgStatusDir, e.Name()))
+			}
+		}
+	}
+
+	for _, status := range statuses {
+		var pkg *Package
+		err := readStanzas(status, func(fields map[string]string) bool {
+			if fields["Package"] != name {
+				return true
+			}
+			if s := fields["Status"]; s != "" && !strings.HasSuffix(s, " installed") {
+				return true
+			}
+
+			pkg = &Package{
+				Name:    name,
+				Version: fields["Version"],
+				Manager: PackageManagerDpkg,
+			}
+
+			return false
+		})
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		if pkg != nil {
+			return pkg, nil
+		}
+	}
+
+	return &Package{
+		Name:    name,
+		Manager: PackageManagerDpkg,
+	}, nil
+}
+
+// findOwner searches the info/<package>.list files and, for distroless
+// images, the status.d/<package>.md5sums files for the path.
+func (dpkgDatabase) findOwner(root string, paths []string) (string, error) {
+	sources := []struct {
+		dir    string
+		suffix string
+		toPath func(string) string
+	}{
+		{
+			dir:    dpkgInfoDir,
+			suffix: ".list",
+			toPath: strings.TrimSpace,
+		},
+		{
+			dir:    dpkgStatusDir,
+			suffix: ".md5sums",
+			toPath: func(line string) string {
+				if _, p, found := strings.Cut(line, "  "); found {
+					return "/" + strings.TrimSpace(p)
+				}
+
+				return ""
+			},
+		},
+	}
+
+	for _, source := range sources {
+		entries, err := os.ReadDir(filepath.Join(root, source.dir))
+		if err != nil {
+			continue
+		}
+
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), source.suffix) {
+				continue
+			}
+
+			found, err := fileContainsLine(filepath.Join(root, source.dir, e.Name()), func(line string) bool {
+				for _, p := range paths {
+					if source.toPath(line) == p {
+						return true
+					}
+				}
+
+				return false
+			})
+			if err != nil {
+				continue
+			}
+
+			if found {
+				name := strings.TrimSuffix(e.Name(), source.suffix)
+				// multiarch packages are listed as <package>:<arch>
+				name, _, _ = strings.Cut(name, ":")
+
+				return name, nil
+			}
+		}
+	}
+
+	return "", PackageNotFoundError
+}
+
+type apkDatabase struct{}
+
+func (apkDatabase) manager() string {
+	return PackageManagerAPK
+}
+
+func (apkDatabase) databaseFile(root string) (string, bool) {
+	p := filepath.Join(root, apkInstalledFile)
+	if _, err := os.Stat(p); err != nil {
+		return "", false
+	}
+
+	return p, true
+}
+
+// lookup parses the installed database, where each package stanza lists
+// its directories as F: records followed by the R: records of their files.
+func (apkDatabase) lookup(root string, paths []string) (*Package, error) {
+	f, err := os.Open(filepath.Join(root, apkInstalledFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var name, version, dir string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			name, version, dir = "", "", ""
+
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "P":
+			name = value
+		case "V":
+			version = value
+		case "F":
+			dir = value
+		case "R":
+			for _, p := range paths {
+				if p == "/"+path.Join(dir, value) {
+					return &Package{
+						Name:    name,
+						Version: version,
+						Manager: PackageManagerAPK,
+					}, nil
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, PackageNotFoundError
+}
+
+type rpmDatabase struct{}
+
+func (rpmDatabase) manager() string {
+	return PackageManagerRPM
+}
+
+func (rpmDatabase) databaseFile(root string) (string, bool) {
+	for _, p := range rpmDatabaseFiles {
+		if _, err := os.Stat(filepath.Join(root, p)); err == nil {
+			return filepath.Join(root, p), true
+		}
+	}
+
+	return "", false
+}
+
+func (db rpmDatabase) lookup(root string, paths []string) (*Package, error) {
+	dbFile, ok := db.databaseFile(root)
+	if !ok {
+		return nil, PackageNotFoundError
+	}
+
+	rdb, err := rpmdb.Open(dbFile)
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+	defer rdb.Close()
+
+	pkgs, err := rdb.ListPackages()
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+
+	for _, pkg := range pkgs {
+		files, err := pkg.InstalledFileNames()
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			for _, p := range paths {
+				if file != p {
+					continue
+				}
+
+				version := pkg.Version + "-" + pkg.Release
+				if pkg.Epoch != nil && *pkg.Epoch > 0 {
+					version = strconv.Itoa(*pkg.Epoch) + ":" + version
+				}
+
+				return &Package{
+					Name:    pkg.Name,
+					Version: version,
+					Manager: PackageManagerRPM,
+				}, nil
+			}
+		}
+	}
+
+	return nil, PackageNotFoundError
+}
+
+// readStanzas calls fn with the fields of every blank line separated stanza
+// of a deb822 style file until fn returns false. Continuation lines are
+// ignored.
+func readStanzas(p string, fn func(map[string]string) bool) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fields := map[string]string{}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(fields) > 0 && !fn(fields) {
+				return nil
+			}
+			fields = map[string]string{}
+
+			continue
+		}
+
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		if k, v, found := strings.Cut(line, ":"); found {
+			fields[k] = strings.TrimSpace(v)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		fn(fields)
+	}
+
+	return nil
+}
+
+func fileContainsLine(p string, match func(string) bool) (bool, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if match(scanner.Text()) {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
@@ -0,0 +1,256 @@
+package procfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// stat(5) field indexes counted from the state field, which is the first
+// field after the parenthesized command name.
+const (
+	statFieldSession = 3
+	statFieldTTY     = 4
+	statFieldPolicy  = 38
+)
+
+var schedulingPolicies = map[int]string{
+	0: "other",
+	1: "fifo",
+	2: "rr",
+	3: "batch",
+	5: "idle",
+	6: "deadline",
+}
+
+type Cgroup struct {
+	HierarchyID int
+	Controllers []string
+	Path        string
+}
+
+type MemoryMap struct {
+	Perms  string
+	Offset uint64
+	Inode  uint64
+	Path   string
+}
+
+func (m MemoryMap) Executable() bool {
+	return strings.Contains(m.Perms, "x")
+}
+
+// FileBacked reports whether the mapping refers to a file rather than to an
+// anonymous or special region such as [heap] or [vdso].
+func (m MemoryMap) FileBacked() bool {
+	return m.Inode != 0 && strings.HasPrefix(m.Path, "/")
+}
+
+// processInfo extends the gopsutil process with the facts it does not expose.
+type processInfo struct {
+	*process.Process
+
+	hostProcPath string
+}
+
+func NewProcessInfo(ctx context.Context, pid int32, hostProcPath string) (ProcessInfo, error) {
+	p, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &processInfo{
+		Process:      p,
+		hostProcPath: hostProcPath,
+	}, nil
+}
+
+func (p *processInfo) RootWithContext(ctx context.Context) (string, error) {
+	root, err := os.Readlink(p.path("root"))
+	if err != nil {
+		return "", errors.WrapIf(err, "could not read root link")
+	}
+
+	return root, nil
+}
+
+func (p *processInfo) SessionWithContext(ctx context.Context) (int32, error) {
+	fields, err := p.statFields()
+	if err != nil {
+		return 0, err
+	}
+
+	sid, err := strconv.ParseInt(fields[statFieldSession], 10, 32)
+	if err != nil {
+		return 0, errors.WrapIf(err, "could not parse session id")
+	}
+
+	return int32(sid), nil
+}
+
+func (p *processInfo) TTYWithContext(ctx context.Context) (string, error) {
+	fields, err := p.statFields()
+	if err != nil {
+		return "", err
+	}
+
+	ttyNr, err := strconv.ParseUint(fields[statFieldTTY], 10, 32)
+	if err != nil {
+		return "", errors.WrapIf(err, "could not parse tty number")
+	}
+
+	return ttyName(uint32(ttyNr)), nil
+}
+
+func (p *processInfo) SchedulingPolicyWithContext(ctx context.Context) (string, error) {
+	fields, err := p.statFields()
+	if err != nil {
+		return "", err
+	}
+
+	if len(fields) <= statFieldPolicy {
+		return "", errors.NewPlain("scheduling policy is missing from stat")
+	}
+
+	policy, err := strconv.Atoi(fields[statFieldPolicy])
+	if err != nil {
+		return "", errors.WrapIf(err, "could not parse scheduling policy")
+	}
+
+	if name, ok := schedulingPolicies[policy]; ok {
+		return name, nil
+	}
+
+	return strconv.Itoa(policy), nil
+}
+
+func (p *processInfo) CgroupsWithContext(ctx context.Context) ([]Cgroup, error) {
+	content, err := os.ReadFile(p.path("cgroup"))
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not read cgroup file")
+	}
+
+	return parseCgroups(string(content)), nil
+}
+
+func (p *processInfo) MapsWithContext(ctx context.Context) ([]MemoryMap, error) {
+	content, err := os.ReadFile(p.path("maps"))
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not read maps file")
+	}
+
+	return parseMaps(string(content)), nil
+}
+
+func (p *processInfo) statFields() ([]string, error) {
+	content, err := os.ReadFile(p.path("stat"))
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not read stat file")
+	}
+
+	return parseStatFields(string(content))
+}
+
+func (p *processInfo) path(name string) string {
+	return filepath.Join(p.hostProcPath, strconv.Itoa(int(p.Pid)), name)
+}
+
+// parseStatFields returns the fields following the command name, which may
+// itself contain spaces and parentheses.
+func parseStatFields(stat string) ([]string, error) {
+	i := strings.LastIndexByte(stat, ')')
+	if i < 0 {
+		return nil, errors.NewPlain("invalid stat format")
+	}
+
+	fields := strings.Fields(stat[i+1:])
+	if len(fields) <= statFieldTTY {
+		return nil, errors.NewPlain("invalid stat format")
+	}
+
+	return fields, nil
+}
+
+func parseCgroups(content string) []Cgroup {
+	var cgroups []Cgroup
+
+	for _, line := range strings.Split(content, "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+
+		cgroup := Cgroup{
+			HierarchyID: id,
+			Path:        parts[2],
+		}
+		if parts[1] != "" {
+			cgroup.Controllers = strings.Split(parts[1], ",")
+		}
+
+		cgroups = append(cgroups, cgroup)
+	}
+
+	return cgroups
+}
+
+// parseMaps parses the address, perms, offset, dev, inode and pathname
+// columns of a maps file. The pathname may contain spaces and is absent for
+// anonymous mappings.
+func parseMaps(content string) []MemoryMap {
+	var maps []MemoryMap
+
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.SplitN(line, " ", 6)
+		if len(fields) < 5 {
+			continue
+		}
+
+		m := MemoryMap{
+			Perms: fields[1],
+		}
+		m.Offset, _ = strconv.ParseUint(fields[2], 16, 64)
+		m.Inode, _ = strconv.ParseUint(fields[4], 10, 64)
+		if len(fields) == 6 {
+			m.Path = strings.TrimSpace(fields[5])
+		}
+
+		maps = append(maps, m)
+	}
+
+	return maps
+}
+
+// ttyName converts the tty_nr field of stat to a device name, see devices.txt
+// of the kernel documentation for the major numbers.
+func ttyName(ttyNr uint32) string {
+	if ttyNr == 0 {
+		return ""
+	}
+
+	major := (ttyNr >> 8) & 0xfff
+	minor := (ttyNr & 0xff) | ((ttyNr >> 12) & 0xfff00)
+
+	switch {
+	case major >= 136 && major <= 143:
+		return "pts/" + strconv.Itoa(int((major-136)<<8|minor))
+	case major == 4 && minor < 64:
+		return "tty" + strconv.Itoa(int(minor))
+	case major == 4:
+		return "ttyS" + strconv.Itoa(int(minor-64))
+	case major == 5 && minor == 1:
+		return "console"
+	}
+
+	return strconv.Itoa(int(major)) + ":" + strconv.Itoa(int(minor))
+}
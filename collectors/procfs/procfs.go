@@ -2,16 +2,15 @@ package procfs
 
 import (
 	"context"
-	_ "crypto/sha256"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
-	"github.com/opencontainers/go-digest"
+	"github.com/shirou/gopsutil/v4/common"
 	"github.com/shirou/gopsutil/v4/net"
-	"github.com/shirou/gopsutil/v4/process"
 
 	"github.com/gezacorp/metadatax"
 )
@@ -20,12 +19,24 @@ const (
 	name = "process"
 
 	basePath = "/proc/cmdline"
+
+	deletedMappingSuffix = " (deleted)"
 )
 
 type collector struct {
-	hasProcfs       bool
-	extractEnvs     bool
-	processInfoFunc ProcessInfoFunc
+	hasProcfs         bool
+	extractEnvs       bool
+	detectRuntime     bool
+	extractLibraries  bool
+	hashLibraries     bool
+	networkPeersLimit int
+	hostProcPath      string
+	hostEtcPath       string
+	hostRootPath      string
+	lookupPackages    bool
+	packageResolver   PackageResolver
+	processInfoFunc   ProcessInfoFunc
+	hashCache         *fileHashCache
 
 	mdContainerInitFunc func() metadatax.MetadataContainer
 	skipOnSoftError     bool
@@ -36,12 +47,22 @@ type ProcessInfoFunc func(ctx context.Context, pid int32) (ProcessInfo, error)
 type ProcessInfo interface {
 	NameWithContext(ctx context.Context) (string, error)
 	CmdlineWithContext(ctx context.Context) (string, error)
+	CmdlineSliceWithContext(ctx context.Context) ([]string, error)
 	UidsWithContext(ctx context.Context) ([]uint32, error)
 	GidsWithContext(ctx context.Context) ([]uint32, error)
 	GroupsWithContext(ctx context.Context) ([]uint32, error)
 	EnvironWithContext(ctx context.Context) ([]string, error)
 	ExeWithContext(ctx context.Context) (string, error)
 	ConnectionsWithContext(ctx context.Context) ([]net.ConnectionStat, error)
+	CwdWithContext(ctx context.Context) (string, error)
+	RootWithContext(ctx context.Context) (string, error)
+	CreateTimeWithContext(ctx context.Context) (int64, error)
+	SessionWithContext(ctx context.Context) (int32, error)
+	TTYWithContext(ctx context.Context) (string, error)
+	NiceWithContext(ctx context.Context) (int32, error)
+	SchedulingPolicyWithContext(ctx context.Context) (string, error)
+	CgroupsWithContext(ctx context.Context) ([]Cgroup, error)
+	MapsWithContext(ctx context.Context) ([]MemoryMap, error)
 }
 
 type CollectorOption func(*collector)
@@ -52,6 +73,66 @@ func CollectorWithExtractENVs() CollectorOption {
 	}
 }
 
+func CollectorWithRuntimeDetection() CollectorOption {
+	return func(c *collector) {
+		c.detectRuntime = true
+	}
+}
+
+func CollectorWithLibraries() CollectorOption {
+	return func(c *collector) {
+		c.extractLibraries = true
+	}
+}
+
+func CollectorWithLibraryHashes() CollectorOption {
+	return func(c *collector) {
+		c.extractLibraries = true
+		c.hashLibraries = true
+	}
+}
+
+func CollectorWithNetworkPeers(limit int) CollectorOption {
+	return func(c *collector) {
+		if limit <= 0 {
+			limit = defaultNetworkPeersLimit
+		}
+
+		c.networkPeersLimit = limit
+	}
+}
+
+func CollectorWithHostProcPath(path string) CollectorOption {
+	return func(c *collector) {
+		c.hostProcPath = path
+	}
+}
+
+func CollectorWithHostEtcPath(path string) CollectorOption {
+	return func(c *collector) {
+		c.hostEtcPath = path
+	}
+}
+
+func CollectorWithHostRootPath(path string) CollectorOption {
+	return func(c *collector) {
+		c.hostRootPath = path
+	}
+}
+
+func CollectorWithPackageLookup() CollectorOption {
+	return func(c *collector) {
+		c.lookupPackages = true
+	}
+}
+
+func CollectorWithPackageResolver(resolver PackageResolver) CollectorOption {
+	return func(c *collector) {
+		c.lookupPackages = true
+		c.packageResolver = resolver
+	}
+}
+
 func CollectorWithProcessInfoFunc(fn ProcessInfoFunc) CollectorOption {
 	return func(c *collector) {
 		c.processInfoFunc = fn
@@ -77,15 +158,33 @@ func WithSkipOnSoftError() CollectorOption {
 }
 
 func New(opts ...CollectorOption) metadatax.Collector {
-	c := &collector{}
+	c := &collector{
+		hashCache: newFileHashCache(defaultHashCacheSize),
+	}
 
 	for _, f := range opts {
 		f(c)
 	}
 
+	if c.hostProcPath == "" {
+		c.hostProcPath = procPath()
+	}
+
+	if c.hostEtcPath == "" {
+		c.hostEtcPath = etcPath()
+	}
+
+	if c.hostRootPath == "" {
+		c.hostRootPath = rootPath()
+	}
+
+	if c.lookupPackages && c.packageResolver == nil {
+		c.packageResolver = NewPackageResolver()
+	}
+
 	if c.processInfoFunc == nil {
 		c.processInfoFunc = func(ctx context.Context, pid int32) (ProcessInfo, error) {
-			return process.NewProcessWithContext(ctx, pid)
+			return NewProcessInfo(ctx, pid, c.hostProcPath)
 		}
 	}
 
@@ -110,6 +209,11 @@ func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContaine
 		return nil, metadatax.PIDNotFoundError
 	}
 
+	ctx = context.WithValue(ctx, common.EnvKey, common.EnvMap{
+		common.HostProcEnvKey: c.hostProcPath,
+		common.HostEtcEnvKey:  c.hostEtcPath,
+	})
+
 	processInfo, err := c.processInfoFunc(ctx, pid)
 	if err != nil {
 		if c.skipOnSoftError {
@@ -123,6 +227,7 @@ func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContaine
 
 	getters := []func(context.Context, ProcessInfo, metadatax.MetadataContainer){
 		c.base,
+		c.identity,
 		c.uids,
 		c.gids,
 		c.binary,
@@ -133,6 +238,14 @@ func (c *collector) GetMetadata(ctx context.Context) (metadatax.MetadataContaine
 		getters = append(getters, c.envs)
 	}
 
+	if c.detectRuntime {
+		getters = append(getters, c.runtime)
+	}
+
+	if c.extractLibraries {
+		getters = append(getters, c.libraries)
+	}
+
 	for _, f := range getters {
 		f(ctx, processInfo, md)
 	}
@@ -150,6 +263,54 @@ func (c *collector) base(ctx context.Context, processInfo ProcessInfo, md metada
 	}
 }
 
+func (c *collector) identity(ctx context.Context, processInfo ProcessInfo, md metadatax.MetadataContainer) {
+	if cwd, err := processInfo.CwdWithContext(ctx); err == nil {
+		md.AddLabel("cwd", cwd)
+	}
+
+	if root, err := processInfo.RootWithContext(ctx); err == nil {
+		md.AddLabel("root", root)
+	}
+
+	if createTime, err := processInfo.CreateTimeWithContext(ctx); err == nil && createTime > 0 {
+		md.AddLabel("start-time", time.UnixMilli(createTime).UTC().Format(time.RFC3339Nano))
+	}
+
+	if sid, err := processInfo.SessionWithContext(ctx); err == nil {
+		md.AddLabel("session", strconv.Itoa(int(sid)))
+	}
+
+	if tty, err := processInfo.TTYWithContext(ctx); err == nil {
+		md.AddLabel("tty", tty)
+	}
+
+	if nice, err := processInfo.NiceWithContext(ctx); err == nil {
+		md.AddLabel("nice", strconv.Itoa(int(nice)))
+	}
+
+	if policy, err := processInfo.SchedulingPolicyWithContext(ctx); err == nil {
+		md.AddLabel("scheduling-policy", policy)
+	}
+
+	if cgroups, err := processInfo.CgroupsWithContext(ctx); err == nil {
+		cmd := md.Segment("cgroup")
+		var paths []string
+		for _, cgroup := range cgroups {
+			paths = appendUnique(paths, cgroup.Path)
+			if len(cgroup.Controllers) == 0 && cgroup.HierarchyID == 0 {
+				cmd.AddLabel("unified", cgroup.Path)
+
+				continue
+			}
+
+			for _, controller := range cgroup.Controllers {
+				cmd.AddLabel(strings.TrimPrefix(controller, "name="), cgroup.Path)
+			}
+		}
+		cmd.AddLabel("path", paths...)
+	}
+}
+
 func (c *collector) uids(ctx context.Context, processInfo ProcessInfo, md metadatax.MetadataContainer) {
 	if uids, err := processInfo.UidsWithContext(ctx); err == nil {
 		if len(uids) == 4 {
@@ -157,6 +318,9 @@ func (c *collector) uids(ctx context.Context, processInfo ProcessInfo, md metada
 			uidmd.AddLabel("", strconv.Itoa(int(uids[1])))
 			uidmd.AddLabel("real", strconv.Itoa(int(uids[0])))
 			uidmd.AddLabel("effective", strconv.Itoa(int(uids[1])))
+
+			pid, _ := metadatax.PIDFromContext(ctx)
+			uidmd.AddLabel("name", c.getIDNameResolver(pid).userName(uids[1]))
 		}
 	}
 }
@@ -164,11 +328,15 @@ func (c *collector) uids(ctx context.Context, processInfo ProcessInfo, md metada
 func (c *collector) gids(ctx context.Context, processInfo ProcessInfo, md metadatax.MetadataContainer) {
 	gidmd := md.Segment("gid")
 
+	pid, _ := metadatax.PIDFromContext(ctx)
+	resolver := c.getIDNameResolver(pid)
+
 	if gids, err := processInfo.GidsWithContext(ctx); err == nil {
 		if len(gids) == 4 {
 			gidmd.AddLabel("", strconv.Itoa(int(gids[1])))
 			gidmd.AddLabel("real", strconv.Itoa(int(gids[0])))
 			gidmd.AddLabel("effective", strconv.Itoa(int(gids[1])))
+			gidmd.AddLabel("name", resolver.groupName(gids[1]))
 		}
 	}
 
@@ -200,15 +368,17 @@ func (c *collector) binary(ctx context.Context, processInfo ProcessInfo, md meta
 		bmd.AddLabel("path", exe)
 
 		pid, _ := metadatax.PIDFromContext(ctx)
-		file, err := os.Open(filepath.Join(procPath(), strconv.Itoa(int(pid)), "exe"))
-		if errors.Is(err, os.ErrNotExist) {
-			file, err = os.Open(exe)
+		if c.lookupPackages {
+			c.binaryPackage(pid, exe, bmd)
 		}
+
+		file, err := c.openExecutable(pid, exe)
 		if err != nil {
 			return
 		}
+		defer file.Close()
 
-		hash, err := digest.SHA256.FromReader(file)
+		hash, err := c.hashCache.hashFile(file)
 		if err != nil {
 			return
 		}
@@ -217,21 +387,135 @@ func (c *collector) binary(ctx context.Context, processInfo ProcessInfo, md meta
 	}
 }
 
-func (c *collector) network(ctx context.Context, processInfo ProcessInfo, md metadatax.MetadataContainer) {
-	netmd := md.Segment("network")
-	if conns, err := processInfo.ConnectionsWithContext(ctx); err == nil {
-		var bindings []string
-		for _, conn := range conns {
-			if conn.Status == "LISTEN" {
-				bindings = append(bindings, conn.Laddr.IP+":"+strconv.Itoa(int(conn.Laddr.Port)))
-			}
+func (c *collector) binaryPackage(pid int32, exe string, md metadatax.MetadataContainer) {
+	// the package databases of the host only describe host processes
+	root := c.procRoot(pid)
+	if c.inHostRoot(pid) {
+		root = c.hostRootPath
+	}
+
+	pkg, err := c.packageResolver.GetPackageForPath(root, exe)
+	if err != nil {
+		return
+	}
+
+	md.Segment("package").
+		AddLabel("name", pkg.Name).
+		AddLabel("version", pkg.Version).
+		AddLabel("manager", pkg.Manager)
+}
+
+func (c *collector) libraries(ctx context.Context, processInfo ProcessInfo, md metadatax.MetadataContainer) {
+	maps, err := processInfo.MapsWithContext(ctx)
+	if err != nil {
+		return
+	}
+
+	exe, _ := processInfo.ExeWithContext(ctx)
+	pid, _ := metadatax.PIDFromContext(ctx)
+	root := filepath.Join(c.hostProcPath, strconv.Itoa(int(pid)), "root")
+
+	lmd := md.Segment("library")
+
+	var paths []string
+	for _, m := range maps {
+		if !m.FileBacked() || !m.Executable() || m.Path == exe {
+			continue
 		}
-		if len(bindings) > 0 {
-			netmd.AddLabel("binding", bindings...)
+
+		paths = appendUnique(paths, m.Path)
+	}
+
+	for _, path := range paths {
+		lmd.AddLabel("path", path)
+
+		if !c.hashLibraries || strings.HasSuffix(path, deletedMappingSuffix) {
+			continue
+		}
+
+		// not every library can be hashed, so the pairs are kept as well
+		if hash, err := c.hashCache.hash(filepath.Join(root, path)); err == nil {
+			lmd.AddLabel("hash", hash.String())
+			lmd.AddLabel("path-hash", path+":"+hash.String())
 		}
 	}
 }
 
+func (c *collector) runtime(ctx context.Context, processInfo ProcessInfo, md metadatax.MetadataContainer) {
+	pid, _ := metadatax.PIDFromContext(ctx)
+
+	d := runtimeDetector{
+		rootPath: filepath.Join(c.hostProcPath, strconv.Itoa(int(pid)), "root"),
+	}
+
+	if exe, err := processInfo.ExeWithContext(ctx); err == nil {
+		d.exe = exe
+		if file, err := c.openExecutable(pid, exe); err == nil {
+			d.exePath = file.Name()
+			file.Close()
+		}
+	}
+
+	d.cmdline, _ = processInfo.CmdlineSliceWithContext(ctx)
+	d.maps, _ = processInfo.MapsWithContext(ctx)
+	if envs, err := processInfo.EnvironWithContext(ctx); err == nil {
+		d.envs = parseEnvs(envs)
+	}
+
+	r := d.detect()
+	if r == nil {
+		return
+	}
+
+	rmd := md.Segment("runtime")
+	rmd.AddLabel("name", r.name)
+	rmd.AddLabel("version", r.version)
+	for k, v := range r.labels {
+		rmd.AddLabel(k, v)
+	}
+}
+
+func (c *collector) procRoot(pid int32) string {
+	return filepath.Join(c.hostProcPath, strconv.Itoa(int(pid)), "root")
+}
+
+// inHostRoot reports whether the process sees the root file system of the
+// host, so that files of the host describe it.
+func (c *collector) inHostRoot(pid int32) bool {
+	procRoot, err := os.Stat(c.procRoot(pid))
+	if err != nil {
+		return false
+	}
+
+	hostRoot, err := os.Stat(c.hostRootPath)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(procRoot, hostRoot)
+}
+
+func (c *collector) openExecutable(pid int32, exe string) (*os.File, error) {
+	file, err := os.Open(filepath.Join(c.hostProcPath, strconv.Itoa(int(pid)), "exe"))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(exe)
+	}
+
+	return file, err
+}
+
+func parseEnvs(envs []string) map[string]string {
+	m := make(map[string]string, len(envs))
+
+	for _, env := range envs {
+		if k, v, found := strings.Cut(env, "="); found {
+			m[k] = v
+		}
+	}
+
+	return m
+}
+
 func procPath() string {
 	p := os.Getenv("HOST_PROC")
 	if p != "" {
@@ -241,6 +525,14 @@ func procPath() string {
 	return "/proc"
 }
 
+func rootPath() string {
+	if p := os.Getenv("HOST_ROOT"); p != "" {
+		return filepath.Clean(p)
+	}
+
+	return "/"
+}
+
 func (c *collector) hasProcFS() bool {
 	if c.hasProcfs {
 		return true
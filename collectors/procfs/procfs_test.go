@@ -3,7 +3,11 @@ package procfs_test
 import (
 	"context"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
+	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
@@ -25,6 +29,16 @@ type processInfo struct {
 	agids       []uint32
 	envs        []string
 	connections []net.ConnectionStat
+	cwd         string
+	root        string
+	createTime  int64
+	session     int32
+	tty         string
+	nice        int32
+	policy      string
+	cgroups     []procfs.Cgroup
+	cmdLineArgs []string
+	maps        []procfs.MemoryMap
 }
 
 func (i *processInfo) NameWithContext(ctx context.Context) (string, error) {
@@ -35,6 +49,10 @@ func (i *processInfo) CmdlineWithContext(ctx context.Context) (string, error) {
 	return i.cmdLine, nil
 }
 
+func (i *processInfo) CmdlineSliceWithContext(ctx context.Context) ([]string, error) {
+	return i.cmdLineArgs, nil
+}
+
 func (i *processInfo) UidsWithContext(ctx context.Context) ([]uint32, error) {
 	return []uint32{i.uid, i.uid, i.uid, i.uid}, nil
 }
@@ -59,6 +77,42 @@ func (i *processInfo) ConnectionsWithContext(ctx context.Context) ([]net.Connect
 	return i.connections, nil
 }
 
+func (i *processInfo) CwdWithContext(ctx context.Context) (string, error) {
+	return i.cwd, nil
+}
+
+func (i *processInfo) RootWithContext(ctx context.Context) (string, error) {
+	return i.root, nil
+}
+
+func (i *processInfo) CreateTimeWithContext(ctx context.Context) (int64, error) {
+	return i.createTime, nil
+}
+
+func (i *processInfo) SessionWithContext(ctx context.Context) (int32, error) {
+	return i.session, nil
+}
+
+func (i *processInfo) TTYWithContext(ctx context.Context) (string, error) {
+	return i.tty, nil
+}
+
+func (i *processInfo) NiceWithContext(ctx context.Context) (int32, error) {
+	return i.nice, nil
+}
+
+func (i *processInfo) SchedulingPolicyWithContext(ctx context.Context) (string, error) {
+	return i.policy, nil
+}
+
+func (i *processInfo) CgroupsWithContext(ctx context.Context) ([]procfs.Cgroup, error) {
+	return i.cgroups, nil
+}
+
+func (i *processInfo) MapsWithContext(ctx context.Context) ([]procfs.MemoryMap, error) {
+	return i.maps, nil
+}
+
 func TestGetMetadata(t *testing.T) {
 	t.Parallel()
 
@@ -85,22 +139,46 @@ func TestGetMetadata(t *testing.T) {
 				Status: "LISTEN",
 			},
 		},
+		cwd:        "/srv/app",
+		root:       "/",
+		createTime: 1700000000123,
+		session:    1001,
+		tty:        "pts/0",
+		nice:       -5,
+		policy:     "other",
+		cgroups: []procfs.Cgroup{
+			{HierarchyID: 4, Controllers: []string{"cpu", "cpuacct"}, Path: "/system.slice/app.service"},
+			{HierarchyID: 1, Controllers: []string{"name=systemd"}, Path: "/system.slice/app.service"},
+			{HierarchyID: 0, Path: "/system.slice/app.service"},
+		},
 	}
 
 	expected := map[string][]string{
-		"process:binary:path":     {processInfo.exe},
-		"process:binary:hash":     {processInfo.hash},
-		"process:cmdline":         {processInfo.cmdLine},
-		"process:gid":             {strconv.Itoa(int(processInfo.gid))},
-		"process:gid:additional":  {strconv.Itoa(int(processInfo.agids[0])), strconv.Itoa(int(processInfo.agids[1])), strconv.Itoa(int(processInfo.agids[2])), strconv.Itoa(int(processInfo.agids[3]))},
-		"process:gid:effective":   {strconv.Itoa(int(processInfo.gid))},
-		"process:gid:real":        {strconv.Itoa(int(processInfo.gid))},
-		"process:name":            {processInfo.name},
-		"process:pid":             {strconv.Itoa(int(processInfo.pid))},
-		"process:uid":             {strconv.Itoa(int(processInfo.uid))},
-		"process:uid:effective":   {strconv.Itoa(int(processInfo.uid))},
-		"process:uid:real":        {strconv.Itoa(int(processInfo.uid))},
-		"process:network:binding": {"127.0.0.1:8080"},
+		"process:binary:path":       {processInfo.exe},
+		"process:binary:hash":       {processInfo.hash},
+		"process:cmdline":           {processInfo.cmdLine},
+		"process:gid":               {strconv.Itoa(int(processInfo.gid))},
+		"process:gid:additional":    {strconv.Itoa(int(processInfo.agids[0])), strconv.Itoa(int(processInfo.agids[1])), strconv.Itoa(int(processInfo.agids[2])), strconv.Itoa(int(processInfo.agids[3]))},
+		"process:gid:effective":     {strconv.Itoa(int(processInfo.gid))},
+		"process:gid:real":          {strconv.Itoa(int(processInfo.gid))},
+		"process:name":              {processInfo.name},
+		"process:pid":               {strconv.Itoa(int(processInfo.pid))},
+		"process:uid":               {strconv.Itoa(int(processInfo.uid))},
+		"process:uid:effective":     {strconv.Itoa(int(processInfo.uid))},
+		"process:uid:real":          {strconv.Itoa(int(processInfo.uid))},
+		"process:network:binding":   {"127.0.0.1:8080"},
+		"process:cwd":               {"/srv/app"},
+		"process:root":              {"/"},
+		"process:start-time":        {"2023-11-14T22:13:20.123Z"},
+		"process:session":           {"1001"},
+		"process:tty":               {"pts/0"},
+		"process:nice":              {"-5"},
+		"process:scheduling-policy": {"other"},
+		"process:cgroup:cpu":        {"/system.slice/app.service"},
+		"process:cgroup:cpuacct":    {"/system.slice/app.service"},
+		"process:cgroup:systemd":    {"/system.slice/app.service"},
+		"process:cgroup:unified":    {"/system.slice/app.service"},
+		"process:cgroup:path":       {"/system.slice/app.service"},
 	}
 
 	ctx := metadatax.ContextWithPID(context.Background(), int32(processInfo.pid))
@@ -115,8 +193,464 @@ func TestGetMetadata(t *testing.T) {
 				metadatax.WithPrefix("process"),
 			)
 		}),
+		procfs.CollectorWithHostProcPath(t.TempDir()),
+		procfs.CollectorWithHostEtcPath(t.TempDir()),
 		procfs.WithForceHasProcFS(),
 	).GetMetadata(ctx)
 	assert.Nil(t, err)
 	assert.Equal(t, expected, map[string][]string(md.GetLabels()))
 }
+
+func TestGetMetadataNetwork(t *testing.T) {
+	t.Parallel()
+
+	processInfo := &processInfo{
+		pid: 1002,
+		connections: []net.ConnectionStat{
+			{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Laddr: net.Addr{IP: "0.0.0.0", Port: 8080}, Status: "LISTEN"},
+			{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Laddr: net.Addr{IP: "10.0.0.2", Port: 8080}, Raddr: net.Addr{IP: "10.0.0.3", Port: 41234}, Status: "ESTABLISHED"},
+			{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Laddr: net.Addr{IP: "10.0.0.2", Port: 8080}, Raddr: net.Addr{IP: "10.0.0.4", Port: 41235}, Status: "ESTABLISHED"},
+			{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Laddr: net.Addr{IP: "10.0.0.2", Port: 8080}, Raddr: net.Addr{IP: "10.0.0.5", Port: 41236}, Status: "ESTABLISHED"},
+			{Family: syscall.AF_INET6, Type: syscall.SOCK_STREAM, Laddr: net.Addr{IP: "::", Port: 9090}, Status: "LISTEN"},
+			{Family: syscall.AF_INET, Type: syscall.SOCK_DGRAM, Laddr: net.Addr{IP: "0.0.0.0", Port: 53}, Status: "NONE"},
+			{Family: syscall.AF_INET6, Type: syscall.SOCK_DGRAM, Laddr: net.Addr{IP: "::", Port: 0}, Status: "NONE"},
+			{Family: syscall.AF_UNIX, Type: syscall.SOCK_STREAM, Laddr: net.Addr{IP: "/run/app.sock"}, Status: "NONE"},
+			{Family: syscall.AF_UNIX, Type: syscall.SOCK_STREAM, Laddr: net.Addr{IP: "/run/app.sock"}, Status: "NONE"},
+			{Family: syscall.AF_UNIX, Type: syscall.SOCK_DGRAM, Laddr: net.Addr{IP: "@/tmp/.X11-unix/X0"}, Status: "NONE"},
+			{Family: syscall.AF_UNIX, Type: syscall.SOCK_STREAM, Status: "NONE"},
+		},
+	}
+
+	ctx := metadatax.ContextWithPID(context.Background(), int32(processInfo.pid))
+	md, err := procfs.New(
+		procfs.CollectorWithProcessInfoFunc(
+			func(ctx context.Context, pid int32) (procfs.ProcessInfo, error) {
+				return processInfo, nil
+			},
+		),
+		procfs.CollectorWithNetworkPeers(2),
+		procfs.CollectorWithHostProcPath(t.TempDir()),
+		procfs.CollectorWithHostEtcPath(t.TempDir()),
+		procfs.WithForceHasProcFS(),
+	).GetMetadata(ctx)
+	assert.Nil(t, err)
+
+	expected := map[string][]string{
+		"process:network:binding":                {"0.0.0.0:8080", ":::9090"},
+		"process:network:protocol":               {"tcp", "tcp6", "udp", "udp6", "unix"},
+		"process:network:tcp:listen:count":       {"1"},
+		"process:network:tcp:established:count":  {"3"},
+		"process:network:tcp6:listen:count":      {"1"},
+		"process:network:tcp6:established:count": {"0"},
+		"process:network:udp:binding":            {"0.0.0.0:53"},
+		"process:network:unix:path":              {"/run/app.sock"},
+		"process:network:unix:abstract":          {"@/tmp/.X11-unix/X0"},
+		"process:network:peer":                   {"10.0.0.3:41234", "10.0.0.4:41235"},
+	}
+
+	labels := md.GetLabels()
+	for k, v := range expected {
+		assert.Equal(t, v, labels[k], k)
+	}
+}
+
+func TestGetMetadataIDNames(t *testing.T) {
+	t.Parallel()
+
+	procDir := t.TempDir()
+	etcDir := t.TempDir()
+	hostRoot := t.TempDir()
+
+	rootEtcDir := filepath.Join(procDir, "1003", "root", "etc")
+	assert.Nil(t, os.MkdirAll(rootEtcDir, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(rootEtcDir, "passwd"), []byte("root:x:0:0:root:/root:/bin/sh\napp:x:501:501::/home/app:/bin/sh\n"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(etcDir, "passwd"), []byte("# host users\nhostuser:x:501:20::/Users/hostuser:/bin/zsh\n"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(etcDir, "group"), []byte("staff:x:502:hostuser\n"), 0o600))
+
+	// the root of pid 1004 is the root of the host
+	assert.Nil(t, os.MkdirAll(filepath.Join(procDir, "1004"), 0o755))
+	assert.Nil(t, os.Symlink(hostRoot, filepath.Join(procDir, "1004", "root")))
+
+	getMetadata := func(pid int) metadatax.MetadataContainer {
+		ctx := metadatax.ContextWithPID(context.Background(), int32(pid))
+		md, err := procfs.New(
+			procfs.CollectorWithProcessInfoFunc(
+				func(ctx context.Context, _ int32) (procfs.ProcessInfo, error) {
+					return &processInfo{pid: pid, uid: 501, gid: 502}, nil
+				},
+			),
+			procfs.CollectorWithHostProcPath(procDir),
+			procfs.CollectorWithHostEtcPath(etcDir),
+			procfs.CollectorWithHostRootPath(hostRoot),
+			procfs.WithForceHasProcFS(),
+		).GetMetadata(ctx)
+		assert.Nil(t, err)
+
+		return md
+	}
+
+	// the files of the host do not describe containerized processes
+	md := getMetadata(1003)
+	assert.Equal(t, "app", md.GetLabelValue("process:uid:name"))
+	assert.Empty(t, md.GetLabels()["process:gid:name"])
+
+	md = getMetadata(1004)
+	assert.Equal(t, "hostuser", md.GetLabelValue("process:uid:name"))
+	assert.Equal(t, "staff", md.GetLabelValue("process:gid:name"))
+}
+
+func TestProcessInfo(t *testing.T) {
+	t.Parallel()
+
+	pid := os.Getpid()
+	procDir := t.TempDir()
+	pidDir := filepath.Join(procDir, strconv.Itoa(pid))
+
+	stat := strconv.Itoa(pid) + " (my (app)) S 1 100 100 34816 100 4194560 1 0 0 0 0 0 0 0 20 0 1 0 100 1000 100 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 3 0 0 0 0 0\n"
+	cgroup := "12:memory:/kubepods/burstable/pod1\n1:name=systemd:/kubepods/burstable/pod1\n0::/\n"
+
+	assert.Nil(t, os.MkdirAll(pidDir, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(pidDir, "stat"), []byte(stat), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(pidDir, "cgroup"), []byte(cgroup), 0o600))
+	assert.Nil(t, os.Symlink("/var/lib/containers/rootfs", filepath.Join(pidDir, "root")))
+
+	ctx := context.Background()
+	info, err := procfs.NewProcessInfo(ctx, int32(pid), procDir)
+	assert.Nil(t, err)
+
+	session, err := info.SessionWithContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(100), session)
+
+	tty, err := info.TTYWithContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "pts/0", tty)
+
+	policy, err := info.SchedulingPolicyWithContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "batch", policy)
+
+	root, err := info.RootWithContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "/var/lib/containers/rootfs", root)
+
+	cgroups, err := info.CgroupsWithContext(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []procfs.Cgroup{
+		{HierarchyID: 12, Controllers: []string{"memory"}, Path: "/kubepods/burstable/pod1"},
+		{HierarchyID: 1, Controllers: []string{"name=systemd"}, Path: "/kubepods/burstable/pod1"},
+		{HierarchyID: 0, Path: "/"},
+	}, cgroups)
+}
+
+func TestGetMetadataRuntime(t *testing.T) {
+	t.Parallel()
+
+	goExe, err := os.Executable()
+	assert.Nil(t, err)
+
+	mapped := func(paths ...string) []procfs.MemoryMap {
+		maps := []procfs.MemoryMap{{Perms: "rw-p"}, {Perms: "r-xp", Path: "[vdso]"}}
+		for i, p := range paths {
+			maps = append(maps, procfs.MemoryMap{Perms: "r-xp", Inode: uint64(100 + i), Path: p})
+		}
+
+		return maps
+	}
+
+	testCases := []struct {
+		name     string
+		info     *processInfo
+		files    map[string]string
+		expected map[string][]string
+	}{
+		{
+			name: "jvm main class",
+			info: &processInfo{
+				exe:         "/usr/lib/jvm/java-17-openjdk/bin/java",
+				cmdLineArgs: []string{"java", "-Xmx1g", "-cp", "/app/lib/*", "com.example.Main", "--port", "8080"},
+				maps:        mapped("/usr/lib/x86_64-linux-gnu/libc.so.6", "/usr/lib/jvm/java-17-openjdk/lib/server/libjvm.so"),
+			},
+			files: map[string]string{
+				"usr/lib/jvm/java-17-openjdk/release": "IMPLEMENTOR=\"Eclipse Adoptium\"\nJAVA_VERSION=\"17.0.9\"\n",
+			},
+			expected: map[string][]string{
+				"process:runtime:name":       {"jvm"},
+				"process:runtime:version":    {"17.0.9"},
+				"process:runtime:home":       {"/usr/lib/jvm/java-17-openjdk"},
+				"process:runtime:main-class": {"com.example.Main"},
+			},
+		},
+		{
+			name: "jvm jar",
+			info: &processInfo{
+				exe:         "/opt/java/bin/java",
+				cmdLineArgs: []string{"java", "-XX:+UseG1GC", "-jar", "/app/service.jar"},
+			},
+			expected: map[string][]string{
+				"process:runtime:name": {"jvm"},
+				"process:runtime:home": {"/opt/java"},
+				"process:runtime:jar":  {"/app/service.jar"},
+			},
+		},
+		{
+			name: "python script in virtualenv",
+			info: &processInfo{
+				exe:         "/usr/bin/python3.11",
+				cmdLineArgs: []string{"/srv/venv/bin/python", "-u", "-W", "ignore", "/srv/app/main.py"},
+			},
+			files: map[string]string{
+				"srv/venv/pyvenv.cfg": "home = /usr/bin\nversion = 3.11.4\n",
+			},
+			expected: map[string][]string{
+				"process:runtime:name":       {"python"},
+				"process:runtime:version":    {"3.11"},
+				"process:runtime:script":     {"/srv/app/main.py"},
+				"process:runtime:virtualenv": {"/srv/venv"},
+			},
+		},
+		{
+			name: "python without virtualenv",
+			info: &processInfo{
+				exe:         "/usr/bin/python",
+				cmdLineArgs: []string{"python", "app.py"},
+			},
+			files: map[string]string{
+				"pyvenv.cfg": "home = /usr/bin\nversion = 3.9.0\n",
+			},
+			expected: map[string][]string{
+				"process:runtime:name":   {"python"},
+				"process:runtime:script": {"app.py"},
+			},
+		},
+		{
+			name: "python module",
+			info: &processInfo{
+				exe:         "/usr/local/bin/python3",
+				cmdLineArgs: []string{"python3", "-m", "gunicorn", "app:wsgi"},
+				envs:        []string{"PYTHON_VERSION=3.12.1", "VIRTUAL_ENV=/opt/venv"},
+			},
+			expected: map[string][]string{
+				"process:runtime:name":       {"python"},
+				"process:runtime:version":    {"3.12.1"},
+				"process:runtime:module":     {"gunicorn"},
+				"process:runtime:virtualenv": {"/opt/venv"},
+			},
+		},
+		{
+			name: "node",
+			info: &processInfo{
+				exe:         "/usr/local/bin/node",
+				cmdLineArgs: []string{"node", "--require", "./tracing.js", "--enable-source-maps", "server.js"},
+				envs:        []string{"NODE_VERSION=20.10.0"},
+			},
+			expected: map[string][]string{
+				"process:runtime:name":    {"node"},
+				"process:runtime:version": {"20.10.0"},
+				"process:runtime:script":  {"server.js"},
+			},
+		},
+		{
+			name: "ruby",
+			info: &processInfo{
+				exe:         "/usr/local/bin/ruby",
+				cmdLineArgs: []string{"ruby", "-W0", "bin/rails", "server"},
+				maps:        mapped("/usr/local/lib/libruby.so.3.2.2"),
+			},
+			expected: map[string][]string{
+				"process:runtime:name":    {"ruby"},
+				"process:runtime:version": {"3.2.2"},
+				"process:runtime:script":  {"bin/rails"},
+			},
+		},
+		{
+			name: "dotnet",
+			info: &processInfo{
+				exe:         "/usr/share/dotnet/dotnet",
+				cmdLineArgs: []string{"dotnet", "/app/Api.dll"},
+				maps:        mapped("/usr/share/dotnet/shared/Microsoft.NETCore.App/8.0.1/libcoreclr.so"),
+			},
+			expected: map[string][]string{
+				"process:runtime:name":     {"dotnet"},
+				"process:runtime:version":  {"8.0.1"},
+				"process:runtime:assembly": {"/app/Api.dll"},
+			},
+		},
+		{
+			name: "go",
+			info: &processInfo{
+				exe:         goExe,
+				cmdLineArgs: []string{goExe},
+			},
+			expected: map[string][]string{
+				"process:runtime:name":    {"go"},
+				"process:runtime:version": {strings.TrimPrefix(runtime.Version(), "go")},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tc.info.pid = 2000 + i
+
+			procDir := t.TempDir()
+			rootDir := filepath.Join(procDir, strconv.Itoa(tc.info.pid), "root")
+			for p, content := range tc.files {
+				assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(rootDir, p)), 0o755))
+				assert.Nil(t, os.WriteFile(filepath.Join(rootDir, p), []byte(content), 0o600))
+			}
+
+			ctx := metadatax.ContextWithPID(context.Background(), int32(tc.info.pid))
+			md, err := procfs.New(
+				procfs.CollectorWithProcessInfoFunc(
+					func(ctx context.Context, pid int32) (procfs.ProcessInfo, error) {
+						return tc.info, nil
+					},
+				),
+				procfs.CollectorWithRuntimeDetection(),
+				procfs.CollectorWithHostProcPath(procDir),
+				procfs.CollectorWithHostEtcPath(t.TempDir()),
+				procfs.WithForceHasProcFS(),
+			).GetMetadata(ctx)
+			assert.Nil(t, err)
+
+			labels := md.GetLabels()
+			for k, v := range tc.expected {
+				assert.Equal(t, v, labels[k], k)
+			}
+			for k := range labels {
+				if strings.HasPrefix(k, "process:runtime:") && tc.info.exe != goExe {
+					assert.Contains(t, tc.expected, k)
+				}
+			}
+		})
+	}
+}
+
+func TestGetMetadataLibraries(t *testing.T) {
+	t.Parallel()
+
+	processInfo := &processInfo{
+		pid: 3001,
+		exe: "/usr/bin/app",
+		maps: []procfs.MemoryMap{
+			{Perms: "r-xp", Inode: 10, Path: "/usr/bin/app"},
+			{Perms: "r--p", Inode: 11, Path: "/usr/lib/libfoo.so.1"},
+			{Perms: "r-xp", Inode: 11, Path: "/usr/lib/libfoo.so.1"},
+			{Perms: "r-xp", Inode: 11, Path: "/usr/lib/libfoo.so.1"},
+			{Perms: "r-xp", Inode: 12, Path: "/tmp/inject.so (deleted)"},
+			{Perms: "rw-p", Inode: 13, Path: "/usr/share/locale/locale-archive"},
+			{Perms: "r-xp", Path: "[vdso]"},
+			{Perms: "rwxp"},
+		},
+	}
+
+	procDir := t.TempDir()
+	libDir := filepath.Join(procDir, strconv.Itoa(processInfo.pid), "root", "usr", "lib")
+	assert.Nil(t, os.MkdirAll(libDir, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(libDir, "libfoo.so.1"), []byte("libfoo"), 0o600))
+
+	ctx := metadatax.ContextWithPID(context.Background(), int32(processInfo.pid))
+	md, err := procfs.New(
+		procfs.CollectorWithProcessInfoFunc(
+			func(ctx context.Context, pid int32) (procfs.ProcessInfo, error) {
+				return processInfo, nil
+			},
+		),
+		procfs.CollectorWithLibraryHashes(),
+		procfs.CollectorWithHostProcPath(procDir),
+		procfs.CollectorWithHostEtcPath(t.TempDir()),
+		procfs.WithForceHasProcFS(),
+	).GetMetadata(ctx)
+	assert.Nil(t, err)
+
+	labels := md.GetLabels()
+	assert.Equal(t, []string{"/usr/lib/libfoo.so.1", "/tmp/inject.so (deleted)"}, labels["process:library:path"])
+	assert.Equal(t, []string{digest.SHA256.FromString("libfoo").String()}, labels["process:library:hash"])
+	assert.Equal(t, []string{"/usr/lib/libfoo.so.1:" + digest.SHA256.FromString("libfoo").String()}, labels["process:library:path-hash"])
+}
+
+func TestPackageResolver(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		root     string
+		path     string
+		expected *procfs.Package
+	}{
+		{root: "debian", path: "/usr/bin/env", expected: &procfs.Package{Name: "coreutils", Version: "9.1-1", Manager: "dpkg"}},
+		{root: "debian", path: "/usr/bin/cat", expected: &procfs.Package{Name: "coreutils", Version: "9.1-1", Manager: "dpkg"}},
+		{root: "debian", path: "/usr/lib/x86_64-linux-gnu/libc.so.6", expected: &procfs.Package{Name: "libc6", Version: "2.36-9+deb12u4", Manager: "dpkg"}},
+		{root: "debian", path: "/usr/sbin/nginx"},
+		{root: "distroless", path: "/usr/bin/openssl", expected: &procfs.Package{Name: "openssl", Version: "3.0.11-1~deb12u2", Manager: "dpkg"}},
+		{root: "alpine", path: "/bin/busybox", expected: &procfs.Package{Name: "busybox", Version: "1.36.1-r5", Manager: "apk"}},
+		{root: "alpine", path: "/lib/ld-musl-x86_64.so.1", expected: &procfs.Package{Name: "musl", Version: "1.2.4-r2", Manager: "apk"}},
+		{root: "rhel", path: "/usr/lib64/libuuid.so.1.3.0", expected: &procfs.Package{Name: "libuuid", Version: "2.32.1-42.el8_8", Manager: "rpm"}},
+		{root: "rhel", path: "/usr/bin/uuidgen"},
+	}
+
+	resolver := procfs.NewPackageResolver()
+
+	for _, tc := range testCases {
+		t.Run(tc.root+tc.path, func(t *testing.T) {
+			pkg, err := resolver.GetPackageForPath(filepath.Join("testdata", "packages", tc.root), tc.path)
+			if tc.expected == nil {
+				assert.ErrorIs(t, err, procfs.PackageNotFoundError)
+
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, pkg)
+		})
+	}
+}
+
+func TestGetMetadataBinaryPackage(t *testing.T) {
+	t.Parallel()
+
+	hostRoot, err := filepath.Abs(filepath.Join("testdata", "packages", "alpine"))
+	assert.Nil(t, err)
+
+	containerRoot, err := filepath.Abs(filepath.Join("testdata", "packages", "debian"))
+	assert.Nil(t, err)
+
+	// 3002 runs on the host, 3003 in a container lacking the binary in its
+	// package database
+	procPath := t.TempDir()
+	for pid, root := range map[string]string{"3002": hostRoot, "3003": containerRoot} {
+		assert.Nil(t, os.Mkdir(filepath.Join(procPath, pid), 0o700))
+		assert.Nil(t, os.Symlink(root, filepath.Join(procPath, pid, "root")))
+	}
+
+	collector := procfs.New(
+		procfs.CollectorWithProcessInfoFunc(
+			func(ctx context.Context, pid int32) (procfs.ProcessInfo, error) {
+				return &processInfo{
+					pid: int(pid),
+					exe: "/bin/busybox",
+				}, nil
+			},
+		),
+		procfs.CollectorWithPackageLookup(),
+		procfs.CollectorWithHostProcPath(procPath),
+		procfs.CollectorWithHostEtcPath(t.TempDir()),
+		procfs.CollectorWithHostRootPath(hostRoot),
+		procfs.WithForceHasProcFS(),
+	)
+
+	md, err := collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 3002))
+	assert.Nil(t, err)
+
+	labels := md.GetLabels()
+	assert.Equal(t, []string{"busybox"}, labels["process:binary:package:name"])
+	assert.Equal(t, []string{"1.36.1-r5"}, labels["process:binary:package:version"])
+	assert.Equal(t, []string{"apk"}, labels["process:binary:package:manager"])
+
+	md, err = collector.GetMetadata(metadatax.ContextWithPID(context.Background(), 3003))
+	assert.Nil(t, err)
+
+	assert.Empty(t, md.GetLabels()["process:binary:package:name"])
+}
@@ -0,0 +1,387 @@
+package procfs
+
+import (
+	"bufio"
+	"debug/buildinfo"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	RuntimeJVM    = "jvm"
+	RuntimePython = "python"
+	RuntimeNode   = "node"
+	RuntimeRuby   = "ruby"
+	RuntimeDotNet = "dotnet"
+	RuntimeGo     = "go"
+)
+
+var (
+	pythonVersionRegex = regexp.MustCompile(`(?:^|/)(?:lib)?python(\d+\.\d+)`)
+	rubyVersionRegex   = regexp.MustCompile(`(?:^|/)libruby\.so\.(\d+\.\d+(?:\.\d+)?)`)
+	dotnetVersionRegex = regexp.MustCompile(`/Microsoft\.NETCore\.App/([^/]+)/libcoreclr\.so$`)
+
+	// JVM options which consume the following argument.
+	jvmOptionsWithValue = []string{"-cp", "-classpath", "--class-path", "-p", "--module-path", "--upgrade-module-path", "--add-modules", "--add-opens", "--add-exports", "--add-reads", "--limit-modules", "--patch-module"}
+
+	// Python options which consume the following argument.
+	pythonOptionsWithValue = []string{"-W", "-X", "--check-hash-based-pycs"}
+
+	// Node.js options which consume the following argument.
+	nodeOptionsWithValue = []string{"-r", "--require", "--import", "--loader", "--experimental-loader", "--inspect-port", "--title", "--env-file"}
+)
+
+type processRuntime struct {
+	name    string
+	version string
+	labels  map[string]string
+}
+
+// runtimeDetector identifies the language runtime of a process from its
+// executable, command line, environment and memory mapped files. Files are
+// looked up within the process's root.
+type runtimeDetector struct {
+	rootPath string
+	exePath  string
+	exe      string
+	cmdline  []string
+	envs     map[string]string
+	maps     []MemoryMap
+}
+
+func (d runtimeDetector) detect() *processRuntime {
+	for _, f := range []func() *processRuntime{
+		d.jvm,
+		d.python,
+		d.node,
+		d.ruby,
+		d.dotnet,
+		d.golang,
+	} {
+		if r := f(); r != nil {
+			return r
+		}
+	}
+
+	return nil
+}
+
+func (d runtimeDetector) jvm() *processRuntime {
+	libjvm := d.mappedFile(func(p string) bool {
+		return path.Base(p) == "libjvm.so"
+	})
+	if libjvm == "" && path.Base(d.exe) != "java" {
+		return nil
+	}
+
+	r := &processRuntime{
+		name:   RuntimeJVM,
+		labels: map[string]string{},
+	}
+
+	javaHome := ""
+	if libjvm != "" {
+		// <java.home>/lib/server/libjvm.so or <java.home>/jre/lib/<arch>/server/libjvm.so
+		for dir := path.Dir(libjvm); dir != "/" && dir != "."; dir = path.Dir(dir) {
+			if release := d.readKeyValueFile(path.Join(dir, "release"), "="); release != nil {
+				javaHome = dir
+				r.version = release["JAVA_VERSION"]
+
+				break
+			}
+		}
+	}
+	if javaHome == "" && path.Base(d.exe) == "java" {
+		javaHome = path.Dir(path.Dir(d.exe))
+		if release := d.readKeyValueFile(path.Join(javaHome, "release"), "="); release != nil {
+			r.version = release["JAVA_VERSION"]
+		}
+	}
+	r.labels["home"] = javaHome
+
+	args := d.args()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-jar" && i+1 < len(args):
+			r.labels["jar"] = args[i+1]
+		case (arg == "-m" || arg == "--module") && i+1 < len(args):
+			r.labels["main-class"] = args[i+1]
+		case strings.HasPrefix(arg, "--module="):
+			r.labels["main-class"] = strings.TrimPrefix(arg, "--module=")
+		case slices.Contains(jvmOptionsWithValue, arg):
+			i++
+
+			continue
+		case strings.HasPrefix(arg, "-"):
+			continue
+		default:
+			r.labels["main-class"] = arg
+		}
+
+		break
+	}
+
+	return r
+}
+
+func (d runtimeDetector) python() *processRuntime {
+	version := ""
+	if m := pythonVersionRegex.FindStringSubmatch(path.Base(d.exe)); len(m) > 1 {
+		version = m[1]
+	}
+	libpython := d.mappedFile(func(p string) bool {
+		return strings.HasPrefix(path.Base(p), "libpython")
+	})
+	if m := pythonVersionRegex.FindStringSubmatch(libpython); version == "" && len(m) > 1 {
+		version = m[1]
+	}
+
+	if version == "" && libpython == "" && !strings.HasPrefix(path.Base(d.exe), "python") {
+		return nil
+	}
+
+	r := &processRuntime{
+		name:    RuntimePython,
+		version: version,
+		labels:  map[string]string{},
+	}
+
+	args := d.args()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-m" && i+1 < len(args):
+			r.labels["module"] = args[i+1]
+		case arg == "-c":
+			r.labels["script"] = "-c"
+		case slices.Contains(pythonOptionsWithValue, arg):
+			i++
+
+			continue
+		case strings.HasPrefix(arg, "-"):
+			continue
+		default:
+			r.labels["script"] = arg
+		}
+
+		break
+	}
+
+	r.labels["virtualenv"] = d.pythonVirtualEnv()
+	if r.version == "" {
+		r.version = d.envs["PYTHON_VERSION"]
+	}
+	if venv := r.labels["virtualenv"]; r.version == "" && venv != "" {
+		if cfg := d.readKeyValueFile(path.Join(venv, "pyvenv.cfg"), "="); cfg != nil {
+			r.version = cfg["version"]
+		}
+	}
+
+	return r
+}
+
+func (d runtimeDetector) pythonVirtualEnv() string {
+	if venv := d.envs["VIRTUAL_ENV"]; venv != "" {
+		return venv
+	}
+
+	// <venv>/bin/python holds a pyvenv.cfg in <venv>
+	for _, exe := range []string{d.exe, d.firstArg()} {
+		if !strings.HasPrefix(exe, "/") {
+			continue
+		}
+
+		venv := path.Dir(path.Dir(exe))
+		if d.fileExists(path.Join(venv, "pyvenv.cfg")) {
+			return venv
+		}
+	}
+
+	return ""
+}
+
+func (d runtimeDetector) node() *processRuntime {
+	libnode := d.mappedFile(func(p string) bool {
+		return strings.HasPrefix(path.Base(p), "libnode.so")
+	})
+	if libnode == "" && path.Base(d.exe) != "node" && path.Base(d.exe) != "nodejs" {
+		return nil
+	}
+
+	r := &processRuntime{
+		name:    RuntimeNode,
+		version: strings.TrimPrefix(d.envs["NODE_VERSION"], "v"),
+		labels:  map[string]string{},
+	}
+
+	args := d.args()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if slices.Contains(nodeOptionsWithValue, arg) {
+			i++
+
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		r.labels["script"] = arg
+
+		break
+	}
+
+	return r
+}
+
+func (d runtimeDetector) ruby() *processRuntime {
+	libruby := d.mappedFile(func(p string) bool {
+		return strings.HasPrefix(path.Base(p), "libruby")
+	})
+	if libruby == "" && !strings.HasPrefix(path.Base(d.exe), "ruby") {
+		return nil
+	}
+
+	r := &processRuntime{
+		name:    RuntimeRuby,
+		version: d.envs["RUBY_VERSION"],
+		labels:  map[string]string{},
+	}
+	if m := rubyVersionRegex.FindStringSubmatch(libruby); len(m) > 1 {
+		r.version = m[1]
+	}
+
+	for _, arg := range d.args() {
+		if !strings.HasPrefix(arg, "-") {
+			r.labels["script"] = arg
+
+			break
+		}
+	}
+
+	return r
+}
+
+func (d runtimeDetector) dotnet() *processRuntime {
+	libcoreclr := d.mappedFile(func(p string) bool {
+		return path.Base(p) == "libcoreclr.so"
+	})
+	if libcoreclr == "" && path.Base(d.exe) != "dotnet" {
+		return nil
+	}
+
+	r := &processRuntime{
+		name:   RuntimeDotNet,
+		labels: map[string]string{},
+	}
+	if m := dotnetVersionRegex.FindStringSubmatch(libcoreclr); len(m) > 1 {
+		r.version = m[1]
+	}
+
+	if path.Base(d.exe) == "dotnet" {
+		for _, arg := range d.args() {
+			if strings.HasSuffix(arg, ".dll") {
+				r.labels["assembly"] = arg
+
+				break
+			}
+		}
+	}
+
+	return r
+}
+
+func (d runtimeDetector) golang() *processRuntime {
+	if d.exePath == "" {
+		return nil
+	}
+
+	info, err := buildinfo.ReadFile(d.exePath)
+	if err != nil {
+		return nil
+	}
+
+	return &processRuntime{
+		name:    RuntimeGo,
+		version: strings.TrimPrefix(info.GoVersion, "go"),
+		labels: map[string]string{
+			"module":         info.Main.Path,
+			"module-version": info.Main.Version,
+			"package":        info.Path,
+		},
+	}
+}
+
+func (d runtimeDetector) args() []string {
+	if len(d.cmdline) < 2 {
+		return nil
+	}
+
+	return d.cmdline[1:]
+}
+
+func (d runtimeDetector) firstArg() string {
+	if len(d.cmdline) == 0 {
+		return ""
+	}
+
+	return d.cmdline[0]
+}
+
+func (d runtimeDetector) mappedFile(match func(string) bool) string {
+	for _, m := range d.maps {
+		if m.FileBacked() && match(m.Path) {
+			return m.Path
+		}
+	}
+
+	return ""
+}
+
+func (d runtimeDetector) fileExists(p string) bool {
+	if d.rootPath == "" {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join(d.rootPath, p))
+
+	return err == nil
+}
+
+// readKeyValueFile parses files like the JDK release file and pyvenv.cfg.
+func (d runtimeDetector) readKeyValueFile(p string, sep string) map[string]string {
+	if d.rootPath == "" {
+		return nil
+	}
+
+	f, err := os.Open(filepath.Join(d.rootPath, p))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		k, v, found := strings.Cut(scanner.Text(), sep)
+		if !found {
+			continue
+		}
+
+		v = strings.TrimSpace(v)
+		if uv, err := strconv.Unquote(v); err == nil {
+			v = uv
+		}
+
+		values[strings.TrimSpace(k)] = v
+	}
+
+	return values
+}
@@ -0,0 +1,100 @@
+package procfs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	passwdFile = "passwd"
+	groupFile  = "group"
+)
+
+// idNameResolver maps numeric user and group ids to names using passwd and
+// group files. The process's own root is consulted so that ids of
+// containerized processes resolve the way they do inside the container, the
+// files of the host only for processes running in the root of the host.
+type idNameResolver struct {
+	etcDirs []string
+}
+
+func (c *collector) getIDNameResolver(pid int32) idNameResolver {
+	etcDirs := []string{filepath.Join(c.procRoot(pid), "etc")}
+	if c.inHostRoot(pid) {
+		etcDirs = append(etcDirs, c.hostEtcPath)
+	}
+
+	return idNameResolver{
+		etcDirs: etcDirs,
+	}
+}
+
+func (r idNameResolver) userName(uid uint32) string {
+	return r.lookup(passwdFile, uid)
+}
+
+func (r idNameResolver) groupName(gid uint32) string {
+	return r.lookup(groupFile, gid)
+}
+
+func (r idNameResolver) lookup(file string, id uint32) string {
+	for _, dir := range r.etcDirs {
+		names, err := readIDNames(filepath.Join(dir, file))
+		if err != nil {
+			continue
+		}
+
+		if name, ok := names[id]; ok {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// readIDNames parses passwd(5) and group(5) formatted files, both of which
+// keep the name in the first and the numeric id in the third field.
+func readIDNames(path string) (map[uint32]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	names := map[uint32]string{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := names[uint32(id)]; !ok {
+			names[uint32(id)] = fields[0]
+		}
+	}
+
+	return names, scanner.Err()
+}
+
+func etcPath() string {
+	if p := os.Getenv("HOST_ETC"); p != "" {
+		return filepath.Clean(p)
+	}
+
+	return "/etc"
+}